@@ -1,35 +1,54 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fabienbellanger/goCodeAnalyser/cloc"
-	"github.com/fabienbellanger/goCodeAnalyser/output"
 	"github.com/fabienbellanger/goutils"
 	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // CmdOptions lists all command options.
 type CmdOptions struct {
-	ByFile         bool
-	Debug          bool
-	SkipDuplicated bool
-	OutputType     string
-	ExcludeExt     string
-	IncludeLang    string
-	MatchDir       string
-	NotMatchDir    string
-	Sort           string
+	ByFile             bool
+	Debug              bool
+	SkipDuplicated     bool
+	NoIgnore           bool
+	Exclude            []string
+	MapExt             []string
+	Languages          string
+	Config             string
+	Strict             bool
+	NoTotal            bool
+	CollapseFiles      bool
+	OutputType         string
+	OutputTemplate     string
+	Output             string
+	ExcludeExt         string
+	IncludeLang        string
+	MatchDir           string
+	NotMatchDir        string
+	Sort               string
+	Jobs               int
+	MinifiedLineLength int
+	Mixed              string
+	Embedded           string
+	Timeout            time.Duration
 }
 
 const (
 	appName = "Go Code Analyser"
-	version = "0.1.0"
+	version = cloc.Version
 )
 
 var (
@@ -44,6 +63,7 @@ var (
 		Short:   "goCodeAnalyser [paths]",
 		Long:    "goCodeAnalyser [paths]",
 		Version: version,
+		Args:    cobra.ArbitraryArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			tStart := time.Now()
 
@@ -56,32 +76,52 @@ var (
 				return
 			}
 
-			// List of all available languages
-			// -------------------------------
-			languages := cloc.NewDefinedLanguages()
+			// Configuration, languages and options
+			// ------------------------------------
+			languages, appOpts, err := prepareAnalysis(cmd, args[0])
+			if err != nil {
+				goutils.CheckError(err, 1)
+			}
 
-			// Fill application options
-			// ------------------------
-			appOpts := fillOptions(cmdOpts, languages)
+			// Output writers
+			// --------------
+			targets, err := newOutputTargets(cmdOpts)
+			if err != nil {
+				goutils.CheckError(err, 1)
+			}
+			appOpts.KeepFiles = needFiles(targets)
 
 			// Launch process
 			// --------------
-			// TODO: To implement
+			ctx, cancel := newContext(cmdOpts.Timeout)
+			defer cancel()
+
 			processor := cloc.NewProcessor(languages, appOpts, args)
-			result, err := processor.Analyze()
-			if err != nil {
-				goutils.CheckError(err, 1)
+			result, analyzeErr := processor.AnalyzeContext(ctx)
+			if result == nil {
+				goutils.CheckError(analyzeErr, 1)
+			}
+			if analyzeErr != nil {
+				fmt.Fprintln(os.Stderr, color.Sprintf(color.Yellow("Analysis stopped (%v), displaying partial results"), analyzeErr))
 			}
 
 			// Display results
 			// ---------------
-			// TODO: Switch output!
-			var w output.Writer
-			w = output.NewConsole()
-			w.Write(result, appOpts)
+			if err := writeOutputs(targets, result, appOpts); err != nil {
+				goutils.CheckError(err, 1)
+			}
 
-			fmt.Printf("\nNumber of CPU: %d\n", runtime.NumCPU())
-			displayDuration(time.Since(tStart))
+			if hasConsoleOnStdout(targets) {
+				fmt.Printf("\nNumber of CPU: %d\n", runtime.NumCPU())
+				displayDuration(time.Since(tStart))
+			}
+
+			if analyzeErr != nil {
+				goutils.CheckError(analyzeErr, 1)
+			}
+			if cmdOpts.Strict && len(result.Errors) > 0 {
+				goutils.CheckError(fmt.Errorf("%d file(s) could not be analyzed", len(result.Errors)), 1)
+			}
 		},
 	}
 )
@@ -96,14 +136,32 @@ func Execute() error {
 	// -----
 	rootCommand.Flags().BoolVar(&cmdOpts.ByFile, "files", false, "Display by file")
 	rootCommand.Flags().BoolVar(&cmdOpts.Debug, "debug", false, "Display debug log")
-	rootCommand.Flags().BoolVar(&cmdOpts.SkipDuplicated, "skip-duplicated", false, "Skip duplicated files")
-	rootCommand.Flags().StringVar(&cmdOpts.OutputType, "output-type", "", "Output type [values: default,json,html]")
-	rootCommand.Flags().StringVar(&cmdOpts.ExcludeExt, "exclude-ext", "", "Exclude file name extensions (separated commas)")
-	rootCommand.Flags().StringVar(&cmdOpts.IncludeLang, "include-lang", "", "Include language name (separated commas)")
-	rootCommand.Flags().StringVar(&cmdOpts.MatchDir, "match-dir", "", "Include dir name (regex)")
-	rootCommand.Flags().StringVar(&cmdOpts.NotMatchDir, "not-match-dir", "", "Exclude dir name (regex)")
+	addAnalysisFlags(rootCommand.Flags())
+	rootCommand.Flags().BoolVar(&cmdOpts.Strict, "strict", false, "Exit with an error if a file could not be analyzed")
+	rootCommand.Flags().BoolVar(&cmdOpts.NoTotal, "no-total", false, "Hide total row (csv and tsv outputs)")
+	rootCommand.Flags().BoolVar(&cmdOpts.CollapseFiles, "collapse-files", false, "Collapse files list in a <details> block (markdown output)")
+	rootCommand.Flags().StringVar(&cmdOpts.OutputType, "output-type", "", "Output types separated by commas, optionally with a file path (ex.: console,json:report.json) [values: default,console,json,html,xml,yaml,csv,tsv,markdown]")
+	rootCommand.Flags().StringVar(&cmdOpts.OutputTemplate, "output-template", "", "Output text/template file path")
+	rootCommand.Flags().StringVar(&cmdOpts.Output, "output", "", "Output file path (default stdout)")
+	rootCommand.Flags().IntVar(&cmdOpts.Jobs, "jobs", runtime.NumCPU(), "Number of files analyzed concurrently")
+	rootCommand.Flags().DurationVar(&cmdOpts.Timeout, "timeout", 0, "Stop analysis after this duration and display partial results (ex.: 30s, 0 for no timeout)")
 	rootCommand.Flags().StringVar(&cmdOpts.Sort, "sort", "code", "Sort languages based on column [possible values: files, lines, blanks, code, comments or size]")
 
+	// Sub-commands
+	// ------------
+	initCommand.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing configuration file")
+	rootCommand.AddCommand(initCommand)
+
+	languagesCommand.Flags().StringVar(&cmdOpts.Languages, "languages", "", "Languages definitions file path (YAML), merged over built-in languages")
+	languagesCommand.Flags().StringSliceVar(&cmdOpts.MapExt, "map-ext", []string{}, "Map file name extensions to languages (ex.: inc:PHP,tpl:HTML)")
+	languagesCommand.Flags().StringVar(&cmdOpts.Config, "config", "", "Configuration file path (default: "+configFileName+" found from the current directory upward)")
+	languagesCommand.Flags().BoolVar(&languagesJSON, "json", false, "Display languages in JSON")
+	rootCommand.AddCommand(languagesCommand)
+
+	explainCommand.Flags().BoolVar(&explainJSON, "json", false, "Display explanation in JSON")
+	addAnalysisFlags(explainCommand.Flags())
+	rootCommand.AddCommand(explainCommand)
+
 	// Launch root command
 	// -------------------
 	if err := rootCommand.Execute(); err != nil {
@@ -112,48 +170,221 @@ func Execute() error {
 	return nil
 }
 
-// fillOptions fills applications options from command options.
-// TODO: Test
-func fillOptions(cmdOpts CmdOptions, languages *cloc.DefinedLanguages) *cloc.Options {
-	// Checks sort values
+// addAnalysisFlags adds flags which change the way files are analyzed.
+func addAnalysisFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&cmdOpts.SkipDuplicated, "skip-duplicated", false, "Skip duplicated files")
+	flags.BoolVar(&cmdOpts.NoIgnore, "no-ignore", false, "Do not respect .gitignore, .ignore and .gcaignore files")
+	flags.StringSliceVar(&cmdOpts.Exclude, "exclude", []string{}, "Exclude paths (gitignore patterns separated by commas)")
+	flags.StringSliceVar(&cmdOpts.MapExt, "map-ext", []string{}, "Map file name extensions to languages (ex.: inc:PHP,tpl:HTML)")
+	flags.StringVar(&cmdOpts.Languages, "languages", "", "Languages definitions file path (YAML), merged over built-in languages")
+	flags.StringVar(&cmdOpts.Config, "config", "", "Configuration file path (default: "+configFileName+" found from the first path upward)")
+	flags.StringVar(&cmdOpts.ExcludeExt, "exclude-ext", "", "Exclude file name extensions (separated commas)")
+	flags.StringVar(&cmdOpts.IncludeLang, "include-lang", "", "Include language name (separated commas)")
+	flags.StringVar(&cmdOpts.MatchDir, "match-dir", "", "Include dir name (regex)")
+	flags.StringVar(&cmdOpts.NotMatchDir, "not-match-dir", "", "Exclude dir name (regex)")
+	flags.StringVar(&cmdOpts.Mixed, "mixed", cloc.MixedAsCode, "Count lines with code and a trailing comment as code, comment or both [possible values: code, comment or both]")
+	flags.StringVar(&cmdOpts.Embedded, "embedded", cloc.EmbeddedRollup, "Count embedded languages (HTML scripts and styles, Vue and Svelte sections, PHP islands, Markdown code blocks) in the file language or in their own languages (files rows are then split by language) [possible values: rollup or separate]")
+	flags.IntVar(&cmdOpts.MinifiedLineLength, "minified-line-length", 5000, "Line length from which a file is reported as minified or generated")
+}
+
+// prepareAnalysis loads the configuration file (found from path upward if
+// --config is not set) and languages definitions, and returns the languages
+// and the application options.
+func prepareAnalysis(cmd *cobra.Command, path string) (*cloc.DefinedLanguages, *cloc.Options, error) {
+	languages, err := loadLanguages(cmd, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Fill application options
+	// ------------------------
+	opts, err := fillOptions(cmdOpts, languages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return languages, opts, nil
+}
+
+// loadLanguages loads the configuration file (found from path upward if
+// --config is not set) and returns built-in languages merged with the
+// languages definitions file.
+// Extensions mappings (--map-ext) are not applied.
+func loadLanguages(cmd *cobra.Command, path string) (*cloc.DefinedLanguages, error) {
+	// Configuration file
 	// ------------------
-	if !cloc.CheckSort(cmdOpts.Sort) {
-		cmdOpts.Sort = "code"
+	configFile := cmdOpts.Config
+	if configFile == "" {
+		configFile, _ = findConfigFile(path)
+	}
+	if configFile != "" {
+		if err := loadConfig(configFile, cmd.Flags(), cmd.Root().Flags()); err != nil {
+			return nil, err
+		}
+	}
+
+	// List of all available languages
+	// -------------------------------
+	languages := cloc.NewDefinedLanguages()
+	if cmdOpts.Languages != "" {
+		if err := languages.LoadDefinitions(cmdOpts.Languages); err != nil {
+			return nil, err
+		}
+	}
+
+	return languages, nil
+}
+
+// mapExtensions maps extensions to languages from ext:Language mappings.
+// Invalid mappings are added to errs.
+func mapExtensions(languages *cloc.DefinedLanguages, mappings []string, errs *OptionErrors) {
+	for _, m := range mappings {
+		parts := strings.SplitN(m, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			errs.add("map-ext", m, "invalid mapping (expected ext:Language)", nil)
+			continue
+		}
+		if _, ok := languages.Langs[parts[1]]; !ok {
+			errs.add("map-ext", parts[1], "unknown language", languageNames(languages))
+			continue
+		}
+		languages.MapExtension(parts[0], parts[1])
 	}
+}
+
+// fillOptions fills applications options from command options.
+// Invalid values are all reported in an OptionErrors error.
+func fillOptions(cmdOpts CmdOptions, languages *cloc.DefinedLanguages) (*cloc.Options, error) {
+	var errs OptionErrors
 
 	opts := cloc.NewOptions()
 	opts.ByFile = cmdOpts.ByFile
 	opts.Debug = cmdOpts.Debug
 	opts.SkipDuplicated = cmdOpts.SkipDuplicated
-	opts.Sort = cmdOpts.Sort
+	opts.NoIgnore = cmdOpts.NoIgnore
+	opts.Exclude = cmdOpts.Exclude
+
+	// Checks numeric values
+	// ---------------------
+	if cmdOpts.Jobs > 0 {
+		opts.Jobs = cmdOpts.Jobs
+	} else {
+		errs.add("jobs", strconv.Itoa(cmdOpts.Jobs), "invalid number of jobs (must be greater than 0)", nil)
+	}
+	if cmdOpts.MinifiedLineLength > 0 {
+		opts.MinifiedLineLength = cmdOpts.MinifiedLineLength
+	} else {
+		errs.add("minified-line-length", strconv.Itoa(cmdOpts.MinifiedLineLength), "invalid line length (must be greater than 0)", nil)
+	}
+
+	// Checks sort values
+	// ------------------
+	if cmdOpts.Sort != "" {
+		if cloc.CheckSort(cmdOpts.Sort) {
+			opts.Sort = cmdOpts.Sort
+		} else {
+			errs.add("sort", cmdOpts.Sort, "invalid sort", cloc.SortTypes)
+		}
+	}
+
+	// Checks mixed lines policy
+	// -------------------------
+	if cmdOpts.Mixed != "" {
+		if cloc.CheckMixed(cmdOpts.Mixed) {
+			opts.MixedLines = cmdOpts.Mixed
+		} else {
+			errs.add("mixed", cmdOpts.Mixed, "invalid mixed lines policy", cloc.MixedPolicies)
+		}
+	}
+
+	// Checks embedded languages policy
+	// --------------------------------
+	if cmdOpts.Embedded != "" {
+		if cloc.CheckEmbedded(cmdOpts.Embedded) {
+			opts.Embedded = cmdOpts.Embedded
+		} else {
+			errs.add("embedded", cmdOpts.Embedded, "invalid embedded languages policy", cloc.EmbeddedPolicies)
+		}
+	}
+
+	// Extensions mapping
+	// ------------------
+	mapExtensions(languages, cmdOpts.MapExt, &errs)
 
 	// Excluded extensions
-	// -------------------
-	for _, ext := range strings.Split(cmdOpts.ExcludeExt, ",") {
-		e, ok := cloc.Extensions[ext]
-		if ok {
-			opts.ExcludeExts[e] = struct{}{}
+	// (ambiguous extensions exclude all their candidate languages)
+	// -----------------------------------------------------------
+	ambiguous := languages.AmbiguousExtensions()
+	for _, ext := range splitList(cmdOpts.ExcludeExt) {
+		name := strings.TrimPrefix(ext, ".")
+		langs, ok := ambiguous[name]
+		if !ok {
+			if lang, isExt := languages.Extensions[name]; isExt {
+				langs = []string{lang}
+			}
+		}
+		if len(langs) == 0 {
+			errs.add("exclude-ext", ext, "unknown extension", extensionNames(languages))
+			continue
+		}
+		for _, lang := range langs {
+			opts.ExcludeExts[lang] = struct{}{}
 		}
 	}
 
 	// Match or not directory
 	// ----------------------
 	if cmdOpts.NotMatchDir != "" {
-		opts.NotMatchDir = regexp.MustCompile(cmdOpts.NotMatchDir)
+		re, err := regexp.Compile(cmdOpts.NotMatchDir)
+		if err != nil {
+			errs = append(errs, &OptionError{Flag: "not-match-dir", Value: cmdOpts.NotMatchDir, Message: "invalid regular expression", Err: err})
+		}
+		opts.NotMatchDir = re
 	}
 	if cmdOpts.MatchDir != "" {
-		opts.MatchDir = regexp.MustCompile(cmdOpts.MatchDir)
+		re, err := regexp.Compile(cmdOpts.MatchDir)
+		if err != nil {
+			errs = append(errs, &OptionError{Flag: "match-dir", Value: cmdOpts.MatchDir, Message: "invalid regular expression", Err: err})
+		}
+		opts.MatchDir = re
 	}
 
 	// Included languages
 	// ------------------
-	for _, lang := range strings.Split(cmdOpts.IncludeLang, ",") {
-		if _, ok := languages.Langs[lang]; ok {
-			opts.IncludeLangs[lang] = struct{}{}
+	for _, lang := range splitList(cmdOpts.IncludeLang) {
+		if _, ok := languages.Langs[lang]; !ok {
+			errs.add("include-lang", lang, "unknown language", languageNames(languages))
+			continue
 		}
+		opts.IncludeLangs[lang] = struct{}{}
 	}
 
-	return opts
+	return opts, errs.err()
+}
+
+// newContext returns the analysis context.
+// It is canceled on SIGINT or when timeout is reached (if timeout is not 0).
+func newContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigChan)
+	}()
+
+	return ctx, cancel
 }
 
 // displayDuration displays commands execution duration.
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+)
+
+func TestFillOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdOpts CmdOptions
+		errors  []string // Flags of expected errors
+		check   func(t *testing.T, opts *cloc.Options, languages *cloc.DefinedLanguages)
+	}{
+		{
+			name:    "default values",
+			cmdOpts: CmdOptions{},
+			check: func(t *testing.T, opts *cloc.Options, languages *cloc.DefinedLanguages) {
+				if opts.Sort != "code" || opts.MixedLines != cloc.MixedAsCode || opts.Embedded != cloc.EmbeddedRollup {
+					t.Errorf("sort: %s, mixed: %s, embedded: %s", opts.Sort, opts.MixedLines, opts.Embedded)
+				}
+			},
+		},
+		{
+			name:    "valid values",
+			cmdOpts: CmdOptions{Sort: "lines", Mixed: "both", Embedded: "separate", Jobs: 3, MatchDir: "^src", NotMatchDir: "vendor"},
+			check: func(t *testing.T, opts *cloc.Options, languages *cloc.DefinedLanguages) {
+				if opts.Sort != "lines" || opts.MixedLines != cloc.MixedAsBoth || opts.Embedded != cloc.EmbeddedSeparate || opts.Jobs != 3 {
+					t.Errorf("sort: %s, mixed: %s, embedded: %s, jobs: %d", opts.Sort, opts.MixedLines, opts.Embedded, opts.Jobs)
+				}
+				if opts.MatchDir == nil || opts.NotMatchDir == nil {
+					t.Errorf("match-dir: %v, not-match-dir: %v", opts.MatchDir, opts.NotMatchDir)
+				}
+			},
+		},
+		{
+			name:    "excluded extensions",
+			cmdOpts: CmdOptions{ExcludeExt: "go,.h"},
+			check: func(t *testing.T, opts *cloc.Options, languages *cloc.DefinedLanguages) {
+				checkKeys(t, opts.ExcludeExts, "C Header", "Go")
+			},
+		},
+		{
+			name:    "excluded ambiguous extensions",
+			cmdOpts: CmdOptions{ExcludeExt: "m,v"},
+			check: func(t *testing.T, opts *cloc.Options, languages *cloc.DefinedLanguages) {
+				checkKeys(t, opts.ExcludeExts, "Coq", "MATLAB", "Mercury", "Objective-C", "Verilog")
+			},
+		},
+		{
+			name:    "included languages",
+			cmdOpts: CmdOptions{IncludeLang: "Go, Rust"},
+			check: func(t *testing.T, opts *cloc.Options, languages *cloc.DefinedLanguages) {
+				checkKeys(t, opts.IncludeLangs, "Go", "Rust")
+			},
+		},
+		{
+			name:    "extensions mapping",
+			cmdOpts: CmdOptions{MapExt: []string{"inc:PHP", ".tpl:HTML"}},
+			check: func(t *testing.T, opts *cloc.Options, languages *cloc.DefinedLanguages) {
+				if languages.Extensions["inc"] != "PHP" || languages.Extensions["tpl"] != "HTML" {
+					t.Errorf("inc: %s, tpl: %s", languages.Extensions["inc"], languages.Extensions["tpl"])
+				}
+			},
+		},
+		{
+			name:    "invalid values",
+			cmdOpts: CmdOptions{Sort: "cod", Mixed: "all", Embedded: "split", ExcludeExt: "gox", IncludeLang: "Golang", MapExt: []string{"inc", "tpl:Htm"}, MatchDir: "(", NotMatchDir: "["},
+			errors:  []string{"sort", "mixed", "embedded", "map-ext", "map-ext", "exclude-ext", "not-match-dir", "match-dir", "include-lang"},
+		},
+		{
+			name:    "invalid numeric values",
+			cmdOpts: CmdOptions{Jobs: -1, MinifiedLineLength: -5000},
+			errors:  []string{"jobs", "minified-line-length"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			languages := cloc.NewDefinedLanguages()
+			opts, err := fillOptions(withDefaults(tt.cmdOpts), languages)
+
+			flags := make([]string, 0)
+			var errs OptionErrors
+			if errors.As(err, &errs) {
+				for _, e := range errs {
+					flags = append(flags, e.Flag)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error type %T: %v", err, err)
+			}
+			if tt.errors == nil {
+				tt.errors = []string{}
+			}
+			if !reflect.DeepEqual(flags, tt.errors) {
+				t.Fatalf("errors flags = %v, want %v (%v)", flags, tt.errors, err)
+			}
+
+			if tt.check != nil {
+				tt.check(t, opts, languages)
+			}
+		})
+	}
+}
+
+func TestFillOptionsSuggestions(t *testing.T) {
+	tests := []struct {
+		cmdOpts    CmdOptions
+		suggestion string
+	}{
+		{CmdOptions{Sort: "cod"}, "code"},
+		{CmdOptions{ExcludeExt: "gox"}, "go"},
+		{CmdOptions{ExcludeExt: "tss"}, "ts"},
+		{CmdOptions{IncludeLang: "Pyhton"}, "Python"},
+		{CmdOptions{IncludeLang: "rust"}, "Rust"},
+	}
+
+	for _, tt := range tests {
+		_, err := fillOptions(withDefaults(tt.cmdOpts), cloc.NewDefinedLanguages())
+		var errs OptionErrors
+		if !errors.As(err, &errs) || len(errs) != 1 {
+			t.Fatalf("%+v: errors = %v, want 1 error", tt.cmdOpts, err)
+		}
+		found := false
+		for _, s := range errs[0].Suggestions {
+			found = found || s == tt.suggestion
+		}
+		if !found {
+			t.Errorf("%+v: suggestions = %v, want %s", tt.cmdOpts, errs[0].Suggestions, tt.suggestion)
+		}
+	}
+}
+
+// withDefaults sets the default values of unset numeric flags.
+func withDefaults(cmdOpts CmdOptions) CmdOptions {
+	if cmdOpts.Jobs == 0 {
+		cmdOpts.Jobs = 1
+	}
+	if cmdOpts.MinifiedLineLength == 0 {
+		cmdOpts.MinifiedLineLength = 5000
+	}
+	return cmdOpts
+}
+
+// checkKeys checks the keys of a set.
+func checkKeys(t *testing.T, set map[string]struct{}, keys ...string) {
+	t.Helper()
+
+	got := make([]string, 0, len(set))
+	for k := range set {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, keys) {
+		t.Errorf("keys = %v, want %v", got, keys)
+	}
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// configFileName is the name of the project configuration file.
+const configFileName = ".goCodeAnalyser.yaml"
+
+// configIgnoredFlags lists flags which cannot be set in configuration file.
+var configIgnoredFlags = map[string]struct{}{
+	"config":  {},
+	"help":    {},
+	"version": {},
+}
+
+// configPathFlags lists flags whose values are paths. Relative paths are
+// relative to the configuration file directory.
+var configPathFlags = map[string]struct{}{
+	"languages":       {},
+	"output":          {},
+	"output-template": {},
+}
+
+// configMachineFlags lists flags whose default values depend on the machine.
+// They are not written in default configuration file which is meant to be
+// committed.
+var configMachineFlags = map[string]struct{}{
+	"jobs": {},
+}
+
+var (
+	// initForce overwrites an existing configuration file.
+	initForce bool
+
+	initCommand = &cobra.Command{
+		Use:   "init [dir]",
+		Short: "Write a commented default " + configFileName + " file",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) == 1 {
+				dir = args[0]
+			}
+
+			path := filepath.Join(dir, configFileName)
+			if _, err := os.Stat(path); err == nil && !initForce {
+				return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+			}
+
+			if err := ioutil.WriteFile(path, []byte(defaultConfig(rootCommand.Flags())), 0644); err != nil {
+				return err
+			}
+			fmt.Printf("%s written\n", path)
+
+			return nil
+		},
+	}
+)
+
+// findConfigFile returns the first configuration file found from path upward.
+func findConfigFile(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+		abs = filepath.Dir(abs)
+	}
+
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		file := filepath.Join(dir, configFileName)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return "", false
+		}
+	}
+}
+
+// loadConfig reads a configuration file and sets flags which have not been
+// set in command line. Configuration keys are root command flags names
+// (rootFlags), options of other commands are skipped.
+// Relative paths are resolved from the configuration file directory.
+func loadConfig(path string, flags, rootFlags *pflag.FlagSet) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	config := yaml.MapSlice{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	for _, item := range config {
+		name := fmt.Sprint(item.Key)
+		if _, ignored := configIgnoredFlags[name]; rootFlags.Lookup(name) == nil || ignored {
+			return fmt.Errorf("%s: unknown option '%s'", path, name)
+		}
+
+		// Options of other commands are skipped and
+		// command line flags override configuration file
+		// ----------------------------------------------
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+
+		value := configValue(item.Value)
+		if _, isPath := configPathFlags[name]; isPath {
+			value = configPath(filepath.Dir(path), value)
+		} else if name == "output-type" {
+			value = configOutputTypes(filepath.Dir(path), value)
+		}
+
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("%s: invalid value for '%s': %v", path, name, err)
+		}
+	}
+
+	return nil
+}
+
+// configValue converts a YAML value to a flag value.
+// Lists are joined with commas and maps are converted to key:value lists.
+func configValue(v interface{}) string {
+	switch value := v.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, e := range value {
+			values = append(values, configValue(e))
+		}
+		return strings.Join(values, ",")
+	case yaml.MapSlice:
+		values := make([]string, 0, len(value))
+		for _, e := range value {
+			values = append(values, fmt.Sprintf("%v:%s", e.Key, configValue(e.Value)))
+		}
+		return strings.Join(values, ",")
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// configPath returns a path relative to dir if it is not absolute.
+func configPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// configOutputTypes resolves output files paths of output types from dir
+// (ex.: console,json:report.json).
+func configOutputTypes(dir, value string) string {
+	specs := strings.Split(value, ",")
+	for i, spec := range specs {
+		if parts := strings.SplitN(strings.TrimSpace(spec), ":", 2); len(parts) == 2 {
+			specs[i] = parts[0] + ":" + configPath(dir, parts[1])
+		}
+	}
+	return strings.Join(specs, ",")
+}
+
+// defaultConfig returns a commented default configuration file content.
+func defaultConfig(flags *pflag.FlagSet) string {
+	var sb strings.Builder
+	sb.WriteString("# " + appName + " configuration file\n")
+	sb.WriteString("# ----" + strings.Repeat("-", len(appName)) + "-------------------\n")
+	sb.WriteString("#\n")
+	sb.WriteString("# Command line flags override values of this file.\n")
+	sb.WriteString("# Lists can be written as YAML lists or as comma separated strings.\n")
+
+	names := make([]string, 0)
+	flags.VisitAll(func(f *pflag.Flag) {
+		_, ignored := configIgnoredFlags[f.Name]
+		_, machine := configMachineFlags[f.Name]
+		if !ignored && !machine {
+			names = append(names, f.Name)
+		}
+	})
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := flags.Lookup(name)
+		value := f.DefValue
+		switch f.Value.Type() {
+		case "string":
+			value = fmt.Sprintf("%q", value)
+		case "stringSlice":
+			value = "[" + strings.Trim(value, "[]") + "]"
+		}
+		sb.WriteString(fmt.Sprintf("\n# %s\n# %s: %s\n", f.Usage, name, value))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// explainJSON displays explanation in JSON.
+	explainJSON bool
+
+	explainCommand = &cobra.Command{
+		Use:   "explain <file> [paths]",
+		Short: "Explain how a file is classified and how each of its lines is counted",
+		Long: "Explain how a file is classified and how each of its lines is counted.\n" +
+			"Paths are the analyzed trees the file belongs to (used for ignore files, exclude patterns and duplicates).",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			languages, appOpts, err := prepareAnalysis(cmd, args[0])
+			if err != nil {
+				return err
+			}
+
+			processor := cloc.NewProcessor(languages, appOpts, args[1:])
+			e, err := processor.Explain(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+
+			if explainJSON {
+				data, err := json.MarshalIndent(e, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+			writeExplanation(os.Stdout, e)
+
+			return nil
+		},
+	}
+)
+
+// writeExplanation writes an explanation in console.
+func writeExplanation(w io.Writer, e *cloc.Explanation) {
+	fmt.Fprintf(w, "%-15s %s\n", "File:", e.Path)
+
+	language := "-"
+	if e.Language != "" {
+		language = e.Language
+	}
+	if e.Classification != nil {
+		language += fmt.Sprintf(" (%s: %s)", e.Classification.By, e.Classification.Value)
+	}
+	fmt.Fprintf(w, "%-15s %s\n", "Language:", language)
+
+	status := color.Green("analyzed").String()
+	if e.Skipped != "" {
+		status = color.Yellow("skipped: " + e.Skipped).String()
+	}
+	if e.DuplicateOf != "" {
+		status += " of " + e.DuplicateOf
+	}
+	fmt.Fprintf(w, "%-15s %s\n", "Status:", status)
+
+	if e.File == nil {
+		return
+	}
+	fmt.Fprintf(w, "%-15s %d (code: %d, comment: %d, doc: %d, mixed: %d, blank: %d)\n",
+		"Lines:", e.File.Lines, e.File.Code, e.File.Comments, e.File.Docs, e.File.Mixed, e.File.Blanks)
+	for _, f := range e.File.Embedded {
+		fmt.Fprintf(w, "%-15s %s: %d (code: %d, comment: %d, doc: %d, mixed: %d, blank: %d)\n",
+			"Embedded:", f.Language, f.Lines, f.Code, f.Comments, f.Docs, f.Mixed, f.Blanks)
+	}
+	if e.File.Minified {
+		fmt.Fprintf(w, "%-15s %s\n", "Minified:", "yes")
+	}
+	fmt.Fprintln(w)
+
+	width := len(fmt.Sprint(e.File.Lines))
+	for _, l := range e.Lines {
+		kind := fmt.Sprintf("%-7s", l.Kind)
+		switch l.Kind {
+		case cloc.LineCode:
+			kind = color.Green(kind).String()
+		case cloc.LineComment:
+			kind = color.Cyan(kind).String()
+		case cloc.LineDoc:
+			kind = color.Blue(kind).String()
+		case cloc.LineMixed:
+			kind = color.Magenta(kind).String()
+		default:
+			kind = color.Gray(12, kind).String()
+		}
+		text := l.Text
+		if l.Language != "" {
+			text = color.Gray(12, "["+l.Language+"] ").String() + text
+		}
+		fmt.Fprintf(w, "%*d %s │ %s\n", width, l.Number, kind, text)
+	}
+}
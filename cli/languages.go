@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+	"github.com/fabienbellanger/goutils"
+	"github.com/spf13/cobra"
+)
+
+// languageInfo represents a language definition displayed by the languages command.
+type languageInfo struct {
+	Name           string                 `json:"name"`
+	Extensions     []string               `json:"extensions"`
+	Filenames      []string               `json:"filenames"`
+	Interpreters   []string               `json:"interpreters"`
+	LineComments   []string               `json:"lineComments"`
+	BlockComments  [][]string             `json:"blockComments"`
+	NestedComments [][]string             `json:"nestedComments"`
+	Strings        []cloc.StringDelimiter `json:"strings"`
+}
+
+var (
+	// languagesJSON displays languages in JSON.
+	languagesJSON bool
+
+	languagesCommand = &cobra.Command{
+		Use:   "languages",
+		Short: "List languages with their extensions, file names, shebang interpreters and comments",
+		Long: "List languages with their extensions, file names, shebang interpreters and comments.\n" +
+			"Languages definitions and extensions mappings of the configuration file are applied as for an analysis of the current directory.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			languages, err := loadLanguages(cmd, ".")
+			if err != nil {
+				return err
+			}
+
+			var errs OptionErrors
+			mapExtensions(languages, cmdOpts.MapExt, &errs)
+			if err := errs.err(); err != nil {
+				return err
+			}
+
+			infos := newLanguageInfos(languages)
+			if languagesJSON {
+				return writeLanguagesJSON(os.Stdout, infos)
+			}
+			writeLanguages(os.Stdout, infos)
+
+			return nil
+		},
+	}
+)
+
+// newLanguageInfos returns defined languages sorted by name with their
+// associations inverted from extensions, file names and interpreters maps.
+// Extensions keys which are language names (enry results for ambiguous
+// extensions) are not listed: ambiguous extensions are listed for each of
+// their candidate languages instead.
+func newLanguageInfos(languages *cloc.DefinedLanguages) []*languageInfo {
+	infos := make(map[string]*languageInfo, len(languages.Langs))
+	for key, l := range languages.Langs {
+		infos[key] = &languageInfo{
+			Name:           key,
+			Extensions:     []string{},
+			Filenames:      []string{},
+			Interpreters:   []string{},
+			LineComments:   l.LineComments(),
+			BlockComments:  blockComments(l.MultiLines()),
+			NestedComments: blockComments(l.NestedComments()),
+			Strings:        l.Strings(),
+		}
+		if infos[key].LineComments == nil {
+			infos[key].LineComments = []string{}
+		}
+		if infos[key].Strings == nil {
+			infos[key].Strings = []cloc.StringDelimiter{}
+		}
+	}
+
+	for ext, lang := range languages.Extensions {
+		if _, isLang := languages.Langs[ext]; isLang {
+			continue
+		}
+		if info, ok := infos[lang]; ok {
+			info.Extensions = append(info.Extensions, ext)
+		}
+	}
+	for ext, langs := range languages.AmbiguousExtensions() {
+		for _, lang := range langs {
+			if info, ok := infos[lang]; ok && !goutils.StringInSlice(ext, info.Extensions) {
+				info.Extensions = append(info.Extensions, ext)
+			}
+		}
+	}
+	for name, lang := range languages.Filenames {
+		if info, ok := infos[lang]; ok {
+			info.Filenames = append(info.Filenames, name)
+		}
+	}
+	for interpreter, lang := range languages.Interpreters {
+		if info, ok := infos[lang]; ok {
+			info.Interpreters = append(info.Interpreters, interpreter)
+		}
+	}
+
+	list := make([]*languageInfo, 0, len(infos))
+	for _, info := range infos {
+		sort.Strings(info.Extensions)
+		sort.Strings(info.Filenames)
+		sort.Strings(info.Interpreters)
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+
+	return list
+}
+
+// blockComments returns multi lines comments without the empty delimiters
+// used by languages without block comments.
+func blockComments(multiLines [][]string) [][]string {
+	comments := make([][]string, 0, len(multiLines))
+	for _, ml := range multiLines {
+		if len(ml) == 2 && ml[0] != "" {
+			comments = append(comments, ml)
+		}
+	}
+	return comments
+}
+
+// writeLanguagesJSON writes languages in JSON.
+func writeLanguagesJSON(w io.Writer, infos []*languageInfo) error {
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// writeLanguages writes languages in console.
+func writeLanguages(w io.Writer, infos []*languageInfo) {
+	for _, info := range infos {
+		fmt.Fprintln(w, color.Bold(info.Name))
+		writeLanguageProperty(w, "Extensions", info.Extensions)
+		writeLanguageProperty(w, "File names", info.Filenames)
+		writeLanguageProperty(w, "Interpreters", info.Interpreters)
+		writeLanguageProperty(w, "Line comments", info.LineComments)
+
+		blocks := make([]string, 0, len(info.BlockComments))
+		for _, bc := range info.BlockComments {
+			block := bc[0] + " " + bc[1]
+			for _, n := range info.NestedComments {
+				if n[0] == bc[0] && n[1] == bc[1] {
+					block += " (nested)"
+				}
+			}
+			blocks = append(blocks, block)
+		}
+		writeLanguageProperty(w, "Block comments", blocks)
+
+		strs := make([]string, 0, len(info.Strings))
+		for _, s := range info.Strings {
+			strs = append(strs, s.Begin+" "+s.End)
+		}
+		writeLanguageProperty(w, "Strings", strs)
+	}
+}
+
+// writeLanguageProperty writes a language property if it is not empty.
+func writeLanguageProperty(w io.Writer, name string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  %-15s %s\n", name+":", strings.Join(values, ", "))
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+	"github.com/fabienbellanger/goCodeAnalyser/output"
+)
+
+// outputTarget is an output writer with its destination.
+type outputTarget struct {
+	name   string
+	writer output.Writer
+	path   string // Empty for stdout
+}
+
+// newOutputTargets returns output targets from command options.
+// Output types are separated by commas and each type can have its own
+// destination (ex.: console,json:report.json,html:report.html).
+// Types without destination are written to --output (default stdout).
+func newOutputTargets(cmdOpts CmdOptions) ([]outputTarget, error) {
+	targets := make([]outputTarget, 0)
+
+	if cmdOpts.OutputType != "" || cmdOpts.OutputTemplate == "" {
+		for _, spec := range strings.Split(cmdOpts.OutputType, ",") {
+			parts := strings.SplitN(strings.TrimSpace(spec), ":", 2)
+			name, path := parts[0], cmdOpts.Output
+			if len(parts) == 2 && parts[1] != "" {
+				path = parts[1]
+			}
+
+			w, err := newWriter(name, cmdOpts)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, outputTarget{name: name, writer: w, path: path})
+		}
+	}
+
+	if cmdOpts.OutputTemplate != "" {
+		w, err := output.NewTemplate(cmdOpts.OutputTemplate)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, outputTarget{name: "template", writer: w, path: cmdOpts.Output})
+	}
+
+	return targets, nil
+}
+
+// newWriter returns the output writer corresponding to the output type.
+func newWriter(outputType string, cmdOpts CmdOptions) (output.Writer, error) {
+	switch outputType {
+	case "", "default", "console":
+		return output.NewConsole(), nil
+	case "json":
+		return output.NewJSON(), nil
+	case "html":
+		return output.NewHTML(), nil
+	case "xml":
+		return output.NewXML(), nil
+	case "yaml":
+		return output.NewYAML(), nil
+	case "csv":
+		return output.NewCSV(!cmdOpts.NoTotal), nil
+	case "tsv":
+		return output.NewTSV(!cmdOpts.NoTotal), nil
+	case "markdown":
+		return output.NewMarkdown(cmdOpts.CollapseFiles), nil
+	}
+	return nil, fmt.Errorf("unknown output type: %s", outputType)
+}
+
+// writeOutputs writes result to all targets.
+// Targets sharing the same file are written one after the other.
+func writeOutputs(targets []outputTarget, result *cloc.Result, opts *cloc.Options) error {
+	files := make(map[string]*os.File)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, t := range targets {
+		var w io.Writer = os.Stdout
+		if t.path != "" {
+			f, ok := files[t.path]
+			if !ok {
+				var err error
+				f, err = os.Create(t.path)
+				if err != nil {
+					return err
+				}
+				files[t.path] = f
+			}
+			w = f
+		}
+
+		if err := t.writer.Write(w, result, opts); err != nil {
+			return fmt.Errorf("%s output: %v", t.name, err)
+		}
+	}
+
+	for path, f := range files {
+		delete(files, path)
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// needFiles checks if a target displays files even without --files option.
+func needFiles(targets []outputTarget) bool {
+	for _, t := range targets {
+		switch t.writer.(type) {
+		case *output.HTML, *output.Template:
+			return true
+		}
+	}
+	return false
+}
+
+// hasConsoleOnStdout checks if the console output is written to stdout.
+func hasConsoleOnStdout(targets []outputTarget) bool {
+	for _, t := range targets {
+		if _, ok := t.writer.(*output.Console); ok && t.path == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+)
+
+// maxSuggestions is the max number of suggestions of an option error.
+const maxSuggestions = 3
+
+// OptionError represents an invalid command option value.
+type OptionError struct {
+	Flag        string
+	Value       string
+	Message     string
+	Err         error // Underlying error, if any
+	Suggestions []string
+}
+
+// Error returns the error message with suggestions.
+func (e *OptionError) Error() string {
+	msg := fmt.Sprintf("--%s: %s '%s'", e.Flag, e.Message, e.Value)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(" (did you mean '%s'?)", strings.Join(e.Suggestions, "', '"))
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// OptionErrors lists all invalid command options values.
+type OptionErrors []*OptionError
+
+// Error returns errors messages, one by line.
+func (e OptionErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// add adds an error with suggestions taken from candidates.
+func (e *OptionErrors) add(flag, value, message string, candidates []string) {
+	*e = append(*e, &OptionError{
+		Flag:        flag,
+		Value:       value,
+		Message:     message,
+		Suggestions: suggest(value, candidates),
+	})
+}
+
+// err returns nil if there is no error.
+func (e OptionErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// splitList splits a comma separated list and removes empty values.
+func splitList(list string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// suggest returns the closest candidates of a value.
+// Candidates equal to the value ignoring case come first, then candidates
+// whose edit distance is at most a third of the value length.
+func suggest(value string, candidates []string) []string {
+	type suggestion struct {
+		value    string
+		distance int
+	}
+
+	lower := strings.ToLower(value)
+	maxDistance := len([]rune(value))/3 + 1
+	suggestions := make([]suggestion, 0)
+	for _, c := range candidates {
+		d := levenshtein(lower, strings.ToLower(c))
+		if d <= maxDistance {
+			suggestions = append(suggestions, suggestion{value: c, distance: d})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance == suggestions[j].distance {
+			return suggestions[i].value < suggestions[j].value
+		}
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	values := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		values = append(values, suggestions[i].value)
+	}
+	return values
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// min3 returns the minimum of three integers.
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// languageNames returns names of defined languages.
+func languageNames(languages *cloc.DefinedLanguages) []string {
+	names := make([]string, 0, len(languages.Langs))
+	for name := range languages.Langs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// extensionNames returns file name extensions of defined languages.
+// Ambiguous extensions are included and Extensions keys which are language
+// names (enry results for ambiguous extensions) are not.
+func extensionNames(languages *cloc.DefinedLanguages) []string {
+	exts := make([]string, 0, len(languages.Extensions))
+	for ext := range languages.Extensions {
+		if _, isLang := languages.Langs[ext]; !isLang {
+			exts = append(exts, ext)
+		}
+	}
+	for ext := range languages.AmbiguousExtensions() {
+		if _, ok := languages.Extensions[ext]; !ok {
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"go", "", 2},
+		{"", "go", 2},
+		{"code", "code", 0},
+		{"code", "cod", 1},
+		{"code", "mode", 1},
+		{"kitten", "sitting", 3},
+		{"héllo", "hello", 1},
+	}
+
+	for _, tt := range tests {
+		if d := levenshtein(tt.a, tt.b); d != tt.distance {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, d, tt.distance)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"code", "comments", "blanks", "files", "lines", "size"}
+	tests := []struct {
+		value       string
+		suggestions []string
+	}{
+		{"cod", []string{"code"}},
+		{"Code", []string{"code"}},
+		{"line", []string{"lines", "size"}},
+		{"fils", []string{"files"}},
+		{"xyz", []string{}},
+		{"", []string{}},
+		{"s", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if s := suggest(tt.value, candidates); !reflect.DeepEqual(s, tt.suggestions) {
+				t.Errorf("suggest(%q) = %v, want %v", tt.value, s, tt.suggestions)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		list   string
+		values []string
+	}{
+		{"", []string{}},
+		{"go", []string{"go"}},
+		{"go, js,,ts ", []string{"go", "js", "ts"}},
+	}
+
+	for _, tt := range tests {
+		if values := splitList(tt.list); !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("splitList(%q) = %v, want %v", tt.list, values, tt.values)
+		}
+	}
+}
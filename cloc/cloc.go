@@ -1,11 +1,20 @@
 package cloc
 
 import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"hash"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 )
 
+// Version is the analyser version.
+const Version = "0.1.0"
+
 // Processor represents a process instance
 type Processor struct {
 	langs *DefinedLanguages
@@ -18,17 +27,36 @@ type Result struct {
 	Total     *Language
 	Files     map[string]*File
 	Languages map[string]*Language
+	Errors    []*FileError
+	Minified  []string
+	Elapsed   time.Duration
 }
 
-type syncMap struct {
-	m map[string]*File
-	sync.RWMutex
+// fileJob is a file to analyze by a worker.
+type fileJob struct {
+	index int // Walk order
+	path  string
+	size  int64
 }
 
-func newSyncMap(n int) *syncMap {
-	return &syncMap{
-		m: make(map[string]*File, n),
-	}
+// fileCounters are the counters of an analyzed file kept by a worker until
+// duplicates are known (see reduce).
+type fileCounters struct {
+	index int    // Walk order
+	lang  string // Language key
+	file  *File  // Counters only, unless files are displayed or kept
+}
+
+// partialResult stores the results of a worker.
+// Without duplicates detection, counters are summed by language as files are
+// analyzed. Otherwise, only the first file (in walk order) of each md5sum is
+// kept with its counters.
+type partialResult struct {
+	languages map[string]*Language
+	files     []*File
+	minified  []string
+	hashes    map[[md5.Size]byte]*fileCounters
+	errors    []*FileError
 }
 
 // NewProcessor returns a processor.
@@ -42,115 +70,354 @@ func NewProcessor(langs *DefinedLanguages, options *Options, paths []string) *Pr
 
 // Analyze starts files analysis.
 func (p *Processor) Analyze() (*Result, error) {
-	total := NewLanguage("TOTAL", []string{}, [][]string{{"", ""}})
+	return p.AnalyzeContext(context.Background())
+}
 
-	// List all files and init languages
-	// ---------------------------------
-	languages, err := p.initLanguages()
-	if err != nil {
-		return nil, err
+// AnalyzeContext starts files analysis and stops it when ctx is done.
+// Paths are streamed by the walker to a pool of opts.Jobs workers as soon as
+// they are discovered.
+// If ctx is done before the end of the analysis, the partial result collected
+// so far is returned with the context error.
+func (p *Processor) AnalyzeContext(ctx context.Context) (*Result, error) {
+	tStart := time.Now()
+
+	// Workers
+	// -------
+	jobs := p.opts.Jobs
+	if jobs < 1 {
+		jobs = 1
 	}
+	jobsChan := make(chan fileJob, jobs)
+	partials := make([]*partialResult, jobs)
 
 	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		partials[i] = &partialResult{
+			languages: make(map[string]*Language),
+			hashes:    make(map[[md5.Size]byte]*fileCounters),
+		}
 
-	// Analyze of each filen by language
-	// ---------------------------------
-	syncFiles := newSyncMap(getTotalFiles(languages))
-	// files := make(map[string]*File, getTotalFiles(languages))
-
-	for _, language := range languages {
 		wg.Add(1)
-		go func(language, total *Language, p *Processor, wg *sync.WaitGroup) {
+		go func(partial *partialResult) {
 			defer wg.Done()
 
-			for _, file := range language.Files {
-				// File analysis
-				// -------------
-				f := NewFile(file, language.Name)
-				f.analyze(language, p.opts)
-
-				// Update language
-				// ---------------
-				language.Total++
-				language.Size += f.Size
-				language.Blanks += f.Blanks
-				language.Code += f.Code
-				language.Comments += f.Comments
-				language.Lines += f.Lines
-
-				// Bad performance?
-				syncFiles.Lock()
-				syncFiles.m[file] = f
-				syncFiles.Unlock()
-			}
-
-			// Totals
-			// ------
-			nbFiles := int32(len(language.Files))
-			if len(language.Files) <= 0 {
-				return
+			for job := range jobsChan {
+				if ctx.Err() != nil {
+					continue
+				}
+				p.analyzeFile(ctx, job, partial)
 			}
-			total.Size += language.Size
-			total.Total += nbFiles
-			total.Blanks += language.Blanks
-			total.Comments += language.Comments
-			total.Code += language.Code
-			total.Lines += language.Lines
-		}(language, total, p, &wg)
+		}(partials[i])
 	}
 
+	// Walk paths and send files to workers
+	// ------------------------------------
+	walkErrors, err := p.walk(ctx, jobsChan)
+	close(jobsChan)
 	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil && err != ctx.Err() {
+		return nil, err
+	}
+
+	// Reduce workers results
+	// ----------------------
+	languages, files, total, errors, minified := p.reduce(partials)
+	errors = append(errors, walkErrors...)
+	sortFileErrors(errors)
 
 	return &Result{
 		Total:     total,
-		Files:     syncFiles.m,
+		Files:     files,
 		Languages: languages,
-	}, nil
+		Errors:    errors,
+		Minified:  minified,
+		Elapsed:   time.Since(tStart),
+	}, err
+}
+
+// analyzeFile analyzes a file and adds its counters to the worker partial result.
+// The file is read only once, through a bounded buffer: language detection uses
+// its beginning, and its md5sum is computed while lines are counted.
+// Duplicates are only known once all files are analyzed (see reduce).
+// Files are only kept if they have to be displayed.
+func (p *Processor) analyzeFile(ctx context.Context, job fileJob, partial *partialResult) {
+	// Open file
+	// ---------
+	sf, err := openSourceFile(ctx, job.path)
+	if err != nil {
+		if ctx.Err() == nil {
+			partial.errors = append(partial.errors, newFileError(job.path, PhaseRead, err))
+		}
+		return
+	}
+	defer sf.Close()
+
+	// Get language
+	// ------------
+	lang, _, ok := p.langs.getLanguage(job.path, sf.head, p.opts)
+	if !ok {
+		return
+	}
+	def, ok := p.langs.Langs[lang]
+	if !ok {
+		return
+	}
+
+	// Check options
+	// -------------
+	if _, ok := checkFileOptions(lang, p.opts); !ok {
+		return
+	}
+
+	// File analysis
+	// -------------
+	var h hash.Hash
+	if !p.opts.SkipDuplicated {
+		h = md5.New()
+	}
+
+	f := NewFile(job.path, def.Name)
+	f.Size = job.size
+	if err := f.analyze(newLineReader(sf.reader, h), def, p.langs, p.opts); err != nil {
+		if ctx.Err() == nil {
+			partial.errors = append(partial.errors, newFileError(job.path, PhaseRead, err))
+		}
+		return
+	}
+
+	if p.opts.SkipDuplicated {
+		p.count(partial.languages, lang, f)
+		if f.Minified {
+			partial.minified = append(partial.minified, f.Name)
+		}
+		if p.opts.ByFile || p.opts.KeepFiles {
+			partial.files = append(partial.files, f)
+		}
+		return
+	}
+
+	// Duplicates detection
+	// --------------------
+	if !p.opts.ByFile && !p.opts.KeepFiles && !p.opts.Debug {
+		f = f.counters(p.opts.Embedded == EmbeddedSeparate)
+	}
+	sum := md5Sum(h)
+	if c, ok := partial.hashes[sum]; !ok || job.index < c.index {
+		partial.hashes[sum] = &fileCounters{index: job.index, lang: lang, file: f}
+	}
+}
+
+// md5Sum returns the sum of a md5 hash.
+func md5Sum(h hash.Hash) (sum [md5.Size]byte) {
+	copy(sum[:], h.Sum(nil))
+	return sum
 }
 
-// initLanguages lists all files form paths and inits languages.
-func (p *Processor) initLanguages() (result map[string]*Language, err error) {
-	result = make(map[string]*Language)
-	filesCache := make(map[string]struct{})
+// count adds the counters of a file to the counter of its language
+// (and to the counters of its embedded languages in separate mode).
+func (p *Processor) count(languages map[string]*Language, lang string, f *File) {
+	// language returns the counter of a language key.
+	language := func(lang string) *Language {
+		l, ok := languages[lang]
+		if !ok {
+			l = p.langs.Langs[lang].newCounter()
+			languages[lang] = l
+		}
+		return l
+	}
 
+	l := language(lang)
+	l.add(f)
+	if p.opts.Embedded == EmbeddedSeparate {
+		for _, e := range f.Embedded {
+			l.addEmbedded(language(e.Language), e)
+		}
+	}
+}
+
+// reduce merges workers results once all workers are done and returns
+// languages, files, total, errors and minified files.
+// Among files with the same content, the first one walked is kept.
+// So results never depend on workers scheduling.
+func (p *Processor) reduce(partials []*partialResult) (map[string]*Language, map[string]*File, *Language, []*FileError, []string) {
+	languages := make(map[string]*Language)
+	files := make(map[string]*File)
+	total := NewLanguage("TOTAL", []string{}, [][]string{{"", ""}})
+	errors := make([]*FileError, 0)
+	minified := make([]string, 0)
+
+	// Duplicates
+	// ----------
+	kept := make(map[[md5.Size]byte]*fileCounters)
+	for _, partial := range partials {
+		for hash, c := range partial.hashes {
+			prev, ok := kept[hash]
+			if ok && prev.index < c.index {
+				prev, c = c, prev
+			}
+			if ok && p.opts.Debug {
+				fmt.Printf("[ignore=%v] find same md5\n", prev.file.Name)
+			}
+			kept[hash] = c
+		}
+	}
+	for _, c := range kept {
+		p.count(languages, c.lang, c.file)
+		if c.file.Minified {
+			minified = append(minified, c.file.Name)
+		}
+		if p.opts.ByFile || p.opts.KeepFiles {
+			p.addFile(files, c.file)
+		}
+	}
+
+	// Workers counters
+	// ----------------
+	for _, partial := range partials {
+		errors = append(errors, partial.errors...)
+		minified = append(minified, partial.minified...)
+		for _, f := range partial.files {
+			p.addFile(files, f)
+		}
+		for lang, l := range partial.languages {
+			language, ok := languages[lang]
+			if !ok {
+				language = l.newCounter()
+				languages[lang] = language
+			}
+			language.merge(l)
+		}
+	}
+
+	for _, l := range languages {
+		total.merge(l)
+	}
+	total.Total -= total.embeddedFiles
+	sort.Strings(minified)
+
+	return languages, files, total, errors, minified
+}
+
+// addFile adds a file to the displayed files.
+// In separate mode, the file only counts the lines of its language and each
+// of its embedded languages is displayed as a file named "path (Language)".
+func (p *Processor) addFile(files map[string]*File, f *File) {
+	if p.opts.Embedded != EmbeddedSeparate || len(f.Embedded) == 0 {
+		files[f.Name] = f
+		return
+	}
+
+	host := *f
+	host.Embedded = nil
+	for _, e := range f.Embedded {
+		host.Blanks -= e.Blanks
+		host.Code -= e.Code
+		host.Comments -= e.Comments
+		host.Docs -= e.Docs
+		host.Mixed -= e.Mixed
+		host.Lines -= e.Lines
+
+		embedded := *e
+		embedded.Name = fmt.Sprintf("%s (%s)", f.Name, e.Language)
+		files[embedded.Name] = &embedded
+	}
+	files[host.Name] = &host
+}
+
+// sortFileErrors sorts errors by path and phase.
+func sortFileErrors(errors []*FileError) {
+	sort.Slice(errors, func(i, j int) bool {
+		if errors[i].Path == errors[j].Path {
+			return errors[i].Phase < errors[j].Phase
+		}
+		return errors[i].Path < errors[j].Path
+	})
+}
+
+// walk walks all paths and sends files to analyze to the jobs channel.
+// Files and directories matching opts.Exclude patterns or ignored by
+// .gitignore, .ignore and .gcaignore files (unless opts.NoIgnore is true)
+// are skipped.
+// It stops when ctx is done and returns errors of unreadable paths.
+func (p *Processor) walk(ctx context.Context, jobsChan chan<- fileJob) (errors []*FileError, err error) {
+	index := 0
 	for _, root := range p.paths {
 		vcsInRoot := isVCSDir(root)
+
+		// Ignore files
+		// ------------
+		var ignore *ignoreMatcher
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return errors, err
+		}
+		if !p.opts.NoIgnore || len(p.opts.Exclude) > 0 {
+			ignore, err = newIgnoreMatcher(absRoot, p.opts.Exclude, !p.opts.NoIgnore)
+			if err != nil {
+				errors = append(errors, newFileError(root, PhaseIgnore, err))
+				ignore = nil
+			}
+		}
+
 		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				errors = append(errors, newFileError(path, PhaseWalk, err))
+				return nil
+			}
+
+			if ignore != nil {
+				absPath := absRoot
+				if path != root {
+					rel, err := filepath.Rel(root, path)
+					if err != nil {
+						return nil
+					}
+					absPath = filepath.Join(absRoot, rel)
+
+					if ignore.match(absPath, info.IsDir()) {
+						if info.IsDir() {
+							return filepath.SkipDir
+						}
+						return nil
+					}
+				}
+
+				if info.IsDir() {
+					if err := ignore.loadDir(absPath); err != nil {
+						errors = append(errors, newFileError(path, PhaseIgnore, err))
+					}
+				}
+			}
+
+			if info.IsDir() {
 				return nil
 			}
 
 			// Check if the language is analysable
 			// -----------------------------------
-			if ok := isLanguageAnalysable(path, vcsInRoot, p.opts); !ok {
+			if _, ok := isLanguageAnalysable(path, vcsInRoot, p.opts); !ok {
 				return nil
 			}
 
-			// Check file extension
-			// --------------------
-			if ext, ok := getExtension(path, p.opts); ok {
-				// Get Language
-				// ------------
-				if lang, ok := Extensions[ext]; ok {
-					// Check Options
-					// -------------
-					if ok := checkFileOptions(path, lang, p.opts, filesCache); ok {
-						// Add to languages list
-						// ---------------------
-						if _, ok := result[lang]; !ok {
-							result[lang] = NewLanguage(
-								p.langs.Langs[lang].Name,
-								p.langs.Langs[lang].lineComments,
-								p.langs.Langs[lang].multiLines)
-						}
-						result[lang].Files = append(result[lang].Files, path)
-					}
-				}
+			select {
+			case jobsChan <- fileJob{index: index, path: path, size: info.Size()}:
+			case <-ctx.Done():
+				return ctx.Err()
 			}
+			index++
 
 			return nil
 		})
+		if err != nil {
+			return errors, err
+		}
 	}
 
-	return result, err
+	return errors, nil
 }
@@ -0,0 +1,259 @@
+package cloc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// fixtureSources are the contents of the files of the fixtures trees.
+// Extensions are chosen to go through every language detection:
+// extension, ambiguous extension (enry), file name and shebang.
+var fixtureSources = map[string]string{
+	".go": "// Package p is a package.\npackage p\n\n/* block\n   comment */\nfunc f() string {\n\treturn \"http://x\" // url\n}\n",
+	".c":  "#include <stdio.h>\n\n/* main */\nint main(void) {\n\tputs(\"/*\"); // not a comment\n\treturn 0;\n}\n",
+	".h":  "#ifndef H\n#define H\n\nint f(void); /* f */\n\n#endif\n",
+	".m":  "#import <Foundation/Foundation.h>\n\n@interface A : NSObject\n// comment\n@end\n",
+	".ts": "// comment\nconst a: string = \"//\";\n\nexport default a;\n",
+	"":    "#!/usr/bin/env python\n# comment\nprint('x')\n",
+}
+
+// newFixtureTree writes a tree of n files per kind of source in a temporary
+// directory and returns its path with a cleanup function.
+// One file out of ten is a copy of another one and each C file has a C Header
+// copy (duplicates).
+func newFixtureTree(tb testing.TB, n int) (string, func()) {
+	tb.Helper()
+
+	root, err := ioutil.TempDir("", "cloc")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%02d", i%10))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+
+		// The last file of each ten has the same content as the previous one
+		k := i
+		if i%10 == 9 {
+			k = i - 1
+		}
+
+		for ext, src := range fixtureSources {
+			name := fmt.Sprintf("file%04d%s", i, ext)
+			content := fmt.Sprintf("%s// %d\n", src, k)
+			if ext == "" {
+				name = fmt.Sprintf("script%04d", i)
+				content = fmt.Sprintf("%s# %d\n", src, k)
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				tb.Fatal(err)
+			}
+			if ext == ".c" {
+				name = fmt.Sprintf("file%04d_copy.h", i)
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					tb.Fatal(err)
+				}
+			}
+		}
+	}
+
+	return root, func() {
+		os.RemoveAll(root)
+	}
+}
+
+// analyzeTree analyzes a tree with a number of workers.
+func analyzeTree(tb testing.TB, root string, jobs int) *Result {
+	tb.Helper()
+
+	opts := NewOptions()
+	opts.Jobs = jobs
+	opts.KeepFiles = true
+
+	result, err := NewProcessor(NewDefinedLanguages(), opts, []string{root}).AnalyzeContext(context.Background())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return result
+}
+
+// treeSize returns the total size of the files of a tree.
+func treeSize(tb testing.TB, root string) int64 {
+	tb.Helper()
+
+	var size int64
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return size
+}
+
+// BenchmarkReadTree reads each file of the tree once: it is the I/O cost
+// analysis should stay close to.
+func BenchmarkReadTree(b *testing.B) {
+	root, cleanup := newFixtureTree(b, 500)
+	defer cleanup()
+	b.SetBytes(treeSize(b, root))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			sf, err := openSourceFile(context.Background(), path)
+			if err != nil {
+				return err
+			}
+			defer sf.Close()
+			_, err = io.Copy(ioutil.Discard, sf.reader)
+			return err
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkAnalyze analyzes the tree (each file is read once for language
+// detection, duplicates detection and lines counting).
+func BenchmarkAnalyze(b *testing.B) {
+	for _, jobs := range []int{1, 4} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			root, cleanup := newFixtureTree(b, 500)
+			defer cleanup()
+			b.SetBytes(treeSize(b, root))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				analyzeTree(b, root, jobs)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTotals(t *testing.T) {
+	root, cleanup := newFixtureTree(t, 300)
+	defer cleanup()
+
+	var expected string
+	for _, jobs := range []int{1, 2, 4, 8, 16} {
+		t.Run(fmt.Sprintf("jobs=%d", jobs), func(t *testing.T) {
+			result := analyzeTree(t, root, jobs)
+
+			// Totals are the sum of files results
+			// -----------------------------------
+			sum := NewLanguage("TOTAL", []string{}, [][]string{{"", ""}})
+			for _, f := range result.Files {
+				sum.add(f)
+			}
+			if got, want := counters(result.Total), counters(sum); got != want {
+				t.Errorf("total = %s, files sum = %s", got, want)
+			}
+
+			// Totals are the sum of languages results
+			// ---------------------------------------
+			sum = NewLanguage("TOTAL", []string{}, [][]string{{"", ""}})
+			for _, l := range result.Languages {
+				sum.merge(l)
+			}
+			if got, want := counters(result.Total), counters(sum); got != want {
+				t.Errorf("total = %s, languages sum = %s", got, want)
+			}
+
+			// Duplicates are skipped
+			// ----------------------
+			if got, want := len(result.Files), 270*len(fixtureSources); got != want {
+				t.Errorf("files = %d, want %d", got, want)
+			}
+
+			// Results do not depend on the number of workers
+			// ----------------------------------------------
+			got := counters(result.Total)
+			for _, lang := range sortedLanguages(result) {
+				got += "\n" + lang + ": " + counters(result.Languages[lang])
+			}
+			if expected == "" {
+				expected = got
+			} else if got != expected {
+				t.Errorf("results =\n%s\nwant (1 job)\n%s", got, expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeWithoutFiles(t *testing.T) {
+	root, cleanup := newFixtureTree(t, 100)
+	defer cleanup()
+
+	expected := analyzeTree(t, root, 1)
+	for _, skipDuplicated := range []bool{false, true} {
+		for _, jobs := range []int{1, 4} {
+			t.Run(fmt.Sprintf("skip-duplicated=%v/jobs=%d", skipDuplicated, jobs), func(t *testing.T) {
+				opts := NewOptions()
+				opts.Jobs = jobs
+				opts.SkipDuplicated = skipDuplicated
+
+				result, err := NewProcessor(NewDefinedLanguages(), opts, []string{root}).AnalyzeContext(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Files are not kept
+				// ------------------
+				if len(result.Files) != 0 {
+					t.Errorf("files = %d, want 0", len(result.Files))
+				}
+
+				// Duplicates are counted only if they are not skipped
+				// ---------------------------------------------------
+				want := int32(90 * len(fixtureSources))
+				if skipDuplicated {
+					want = int32(100 * (len(fixtureSources) + 1)) // With C Header copies
+				}
+				if result.Total.Total != want {
+					t.Errorf("total files = %d, want %d", result.Total.Total, want)
+				}
+				if !skipDuplicated {
+					if got, want := counters(result.Total), counters(expected.Total); got != want {
+						t.Errorf("total = %s, want (kept files) %s", got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+// sortedLanguages returns the languages keys of a result sorted by name.
+func sortedLanguages(result *Result) []string {
+	langs := make([]string, 0, len(result.Languages))
+	for lang := range result.Languages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// counters returns the counters of a language.
+func counters(l *Language) string {
+	return fmt.Sprintf("files: %d, size: %d, lines: %d, code: %d, comments: %d, docs: %d, mixed: %d, blanks: %d",
+		l.Total, l.Size, l.Lines, l.Code, l.Comments, l.Docs, l.Mixed, l.Blanks)
+}
@@ -0,0 +1,143 @@
+package cloc
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// StringDelimiter represents string literals delimiters of a language.
+// Escape is the escape character inside the string (empty for raw strings).
+// Strings which are not multiline are closed at the end of the line.
+// Character literals (Char) contain a single, possibly escaped, character:
+// if it is not directly followed by End (which can be empty), Begin is not
+// a delimiter (Rust lifetimes for example).
+type StringDelimiter struct {
+	Begin     string `yaml:"begin" json:"begin"`
+	End       string `yaml:"end" json:"end"`
+	Escape    string `yaml:"escape,omitempty" json:"escape,omitempty"`
+	Multiline bool   `yaml:"multiline,omitempty" json:"multiline,omitempty"`
+	Char      bool   `yaml:"char,omitempty" json:"char,omitempty"`
+}
+
+// LanguageDefinition represents a user-defined language.
+// If a language with the same name already exists, non empty properties
+// replace the existing ones and associations are added to existing ones.
+type LanguageDefinition struct {
+	Name           string            `yaml:"name"`
+	Extensions     []string          `yaml:"extensions"`
+	Filenames      []string          `yaml:"filenames"`
+	Interpreters   []string          `yaml:"interpreters"`
+	LineComments   []string          `yaml:"line_comments"`
+	BlockComments  [][]string        `yaml:"block_comments"`
+	NestedComments [][]string        `yaml:"nested_comments"` // Block comments which can be nested
+	DocLines       []string          `yaml:"doc_line_comments"`
+	DocBlocks      []string          `yaml:"doc_block_comments"`
+	Strings        []StringDelimiter `yaml:"strings"`
+}
+
+// containsBlockComment checks if a block comment is in a list.
+func containsBlockComment(blocks [][]string, bc []string) bool {
+	if len(bc) != 2 {
+		return false
+	}
+	for _, b := range blocks {
+		if len(b) == 2 && b[0] == bc[0] && b[1] == bc[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// languagesFile is the content of a languages definitions file.
+type languagesFile struct {
+	Languages []LanguageDefinition `yaml:"languages"`
+}
+
+// Register adds a language or updates an existing one from its definition.
+func (d *DefinedLanguages) Register(def LanguageDefinition) error {
+	if def.Name == "" {
+		return fmt.Errorf("language name is missing")
+	}
+	for _, bc := range def.BlockComments {
+		if len(bc) != 2 || bc[0] == "" || bc[1] == "" {
+			return fmt.Errorf("%s: block comments must have a begin and an end delimiters", def.Name)
+		}
+	}
+	blocks := def.BlockComments
+	if lang, ok := d.Langs[def.Name]; ok && len(blocks) == 0 {
+		blocks = lang.multiLines
+	}
+	for _, n := range def.NestedComments {
+		if !containsBlockComment(blocks, n) {
+			return fmt.Errorf("%s: nested comments must be block comments", def.Name)
+		}
+	}
+	for _, s := range def.Strings {
+		if s.Begin == "" || (s.End == "" && !s.Char) {
+			return fmt.Errorf("%s: strings must have a begin and an end delimiters", def.Name)
+		}
+	}
+
+	// Language properties
+	// -------------------
+	lang, ok := d.Langs[def.Name]
+	if !ok {
+		lang = NewLanguage(def.Name, []string{}, [][]string{})
+		d.Langs[def.Name] = lang
+	}
+	if len(def.LineComments) > 0 {
+		lang.lineComments = def.LineComments
+	}
+	if len(def.BlockComments) > 0 {
+		lang.multiLines = def.BlockComments
+	}
+	if len(def.NestedComments) > 0 {
+		lang.nested = def.NestedComments
+	}
+	if len(def.Strings) > 0 {
+		lang.strings = def.Strings
+	}
+	if len(def.DocLines) > 0 {
+		lang.docs.lines = def.DocLines
+	}
+	if len(def.DocBlocks) > 0 {
+		lang.docs.blocks = def.DocBlocks
+	}
+
+	// Associations
+	// ------------
+	for _, ext := range def.Extensions {
+		d.MapExtension(ext, def.Name)
+	}
+	for _, name := range def.Filenames {
+		d.Filenames[name] = def.Name
+	}
+	for _, interpreter := range def.Interpreters {
+		d.Interpreters[interpreter] = def.Name
+	}
+
+	return nil
+}
+
+// LoadDefinitions registers languages of a YAML definitions file.
+func (d *DefinedLanguages) LoadDefinitions(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	file := languagesFile{}
+	if err := yaml.UnmarshalStrict(content, &file); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	for _, def := range file.Languages {
+		if err := d.Register(def); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package cloc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// docMarkers represents documentation comments markers of a language.
+type docMarkers struct {
+	lines  []string // Line comments beginning a documentation comment
+	blocks []string // Block comments beginning a documentation comment
+
+	// declaration matches top-level declarations documented by the comments
+	// directly preceding them. member matches indented declarations (struct
+	// fields, grouped declarations) following a top-level line matched by
+	// group; other indented lines (function bodies) are never documented.
+	declaration *regexp.Regexp
+	group       *regexp.Regexp
+	member      *regexp.Regexp
+
+	// docstrings are string delimiters beginning a documentation string
+	// when the string is the first statement of the file or of a definition
+	// matched by definition.
+	docstrings []string
+	definition *regexp.Regexp
+}
+
+var (
+	// goExportedDeclaration matches package clause and exported top-level
+	// declarations.
+	goExportedDeclaration = regexp.MustCompile(`^(?:package\s|func\s+(?:\([^)]*\)\s*)?\p{Lu}|(?:type|var|const)\s+\p{Lu})`)
+	// goGroup matches declarations of struct and interface types and grouped
+	// declarations.
+	goGroup = regexp.MustCompile(`^(?:type|var|const)\s`)
+	// goExportedMember matches exported struct fields, interface methods and
+	// grouped declarations.
+	goExportedMember = regexp.MustCompile(`^\p{Lu}[\p{L}\p{N}_]*(?:\s|,|=|\(|$)`)
+
+	javadocMarkers = docMarkers{blocks: []string{"/**"}}
+	tripleSlash    = docMarkers{lines: []string{"///"}, blocks: []string{"/**"}}
+	docstrings     = docMarkers{
+		docstrings: []string{`"""`, `'''`},
+		definition: regexp.MustCompile(`^(?:async\s+def|def|class)\s`),
+	}
+)
+
+// languageDocs lists documentation comments markers of built-in languages.
+var languageDocs = map[string]docMarkers{
+	"C#":         tripleSlash,
+	"Cython":     docstrings,
+	"Dart":       tripleSlash,
+	"Go":         {declaration: goExportedDeclaration, group: goGroup, member: goExportedMember},
+	"Groovy":     javadocMarkers,
+	"Java":       javadocMarkers,
+	"JavaScript": javadocMarkers,
+	"JSX":        javadocMarkers,
+	"Kotlin":     javadocMarkers,
+	"PHP":        javadocMarkers,
+	"Python":     docstrings,
+	"Rust":       {lines: []string{"///", "//!"}, blocks: []string{"/**", "/*!"}},
+	"Scala":      javadocMarkers,
+	"Swift":      tripleSlash,
+	"TypeScript": javadocMarkers,
+	"Zig":        {lines: []string{"///", "//!"}},
+}
+
+// isDocMarker checks if a documentation marker begins at pos.
+// A marker followed by its last character or by a slash is an ordinary
+// comment (ex.: "////" or "/**/").
+func isDocMarker(line string, pos int, markers []string) bool {
+	for _, m := range markers {
+		if !strings.HasPrefix(line[pos:], m) {
+			continue
+		}
+		next := pos + len(m)
+		if next < len(line) && (line[next] == m[len(m)-1] || line[next] == '/') {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// isIndented checks if a line begins with a space or a tab.
+func isIndented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// documents checks if a trimmed line of code is a declaration documented
+// by the comments preceding it. inGroup is true if the last top-level line
+// of code was matched by group.
+func (d *docMarkers) documents(line string, indented, inGroup bool) bool {
+	if !indented {
+		return d.declaration.MatchString(line)
+	}
+	return inGroup && d.member != nil && d.member.MatchString(line)
+}
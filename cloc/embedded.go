@@ -0,0 +1,203 @@
+package cloc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Embedded languages policies
+// In separate mode, an embedded language counts the lines of its blocks and
+// the files containing them (the total still counts each file once).
+// Displayed files count the lines of their own language, and each of their
+// embedded languages is displayed as a "path (Language)" file.
+const (
+	EmbeddedRollup   = "rollup"   // Embedded lines are counted in the file language
+	EmbeddedSeparate = "separate" // Embedded lines are counted in their own languages
+)
+
+// EmbeddedPolicies lists the possible embedded languages policies.
+var EmbeddedPolicies = []string{EmbeddedRollup, EmbeddedSeparate}
+
+// embeddedSplitter splits a file into blocks of embedded languages.
+type embeddedSplitter interface {
+	// next returns the language key of a trimmed line
+	// (empty for the file language).
+	next(line string) string
+}
+
+var (
+	// embeddedAliases converts common names of code blocks and tags
+	// attributes to extensions.
+	embeddedAliases = map[string]string{
+		"golang":     "go",
+		"javascript": "js",
+		"module":     "js",
+		"shell":      "sh",
+		"typescript": "tsx",
+		"ts":         "tsx",
+		"yml":        "yaml",
+	}
+
+	// tagAttributeRegex matches lang and type attributes of script and style tags.
+	tagAttributeRegex = regexp.MustCompile(`(?i)\s(lang|type)\s*=\s*["']?([^"'\s>]+)`)
+)
+
+// newEmbeddedSplitter returns the splitter of a file language
+// or nil if the language has no embedded languages.
+func (d *DefinedLanguages) newEmbeddedSplitter(lang string) embeddedSplitter {
+	switch lang {
+	case "HTML", "Vue", "Svelte":
+		return &tagSplitter{langs: d}
+	case "PHP":
+		return &phpSplitter{html: d.embeddedLanguage("html"), tags: &tagSplitter{langs: d}}
+	case "Markdown":
+		return &fenceSplitter{langs: d}
+	}
+	return nil
+}
+
+// embeddedLanguage returns the language key of a code block or tag attribute
+// name (extension or language name, case insensitive).
+func (d *DefinedLanguages) embeddedLanguage(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := embeddedAliases[name]; ok {
+		name = alias
+	}
+	if lang, ok := d.Extensions[name]; ok {
+		return lang
+	}
+	for key := range d.Langs {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return ""
+}
+
+// tagSplitter splits HTML, Vue and Svelte files into <script> and <style> blocks.
+// Lines of opening and closing tags belong to the file language.
+type tagSplitter struct {
+	langs   *DefinedLanguages
+	current string // Language of the opened block
+	closing string // Closing tag of the opened block
+}
+
+// next returns the language key of a trimmed line.
+func (s *tagSplitter) next(line string) string {
+	lower := strings.ToLower(line)
+
+	if s.closing != "" {
+		if strings.Contains(lower, s.closing) {
+			s.current, s.closing = "", ""
+			return ""
+		}
+		return s.current
+	}
+
+	for _, tag := range []string{"script", "style"} {
+		i := strings.Index(lower, "<"+tag)
+		if i < 0 {
+			continue
+		}
+		end := strings.Index(lower[i:], ">")
+		if end < 0 || strings.Contains(lower[i+end:], "</"+tag) {
+			// Tag on several lines or inline block
+			return ""
+		}
+
+		s.current = s.tagLanguage(tag, line[i:i+end])
+		if s.current != "" {
+			s.closing = "</" + tag
+		}
+		return ""
+	}
+
+	return ""
+}
+
+// tagLanguage returns the language key of a script or style opening tag.
+// Unknown languages (templates for example) belong to the file language.
+func (s *tagSplitter) tagLanguage(tag, openingTag string) string {
+	name := "js"
+	if tag == "style" {
+		name = "css"
+	}
+
+	for _, m := range tagAttributeRegex.FindAllStringSubmatch(openingTag, -1) {
+		value := strings.ToLower(m[2])
+		if strings.ToLower(m[1]) == "type" {
+			// text/javascript, application/ld+json, module...
+			value = value[strings.LastIndexAny(value, "/+")+1:]
+		}
+		name = value
+	}
+
+	return s.langs.embeddedLanguage(name)
+}
+
+// phpSplitter splits PHP files into PHP islands and HTML, and HTML into
+// <script> and <style> blocks.
+// Lines containing PHP tags belong to PHP.
+type phpSplitter struct {
+	html  string       // HTML language key
+	tags  *tagSplitter // HTML blocks
+	inPHP bool
+}
+
+// next returns the language key of a trimmed line.
+func (s *phpSplitter) next(line string) string {
+	open := strings.LastIndex(line, "<?")
+	closing := strings.LastIndex(line, "?>")
+	if open < 0 && closing < 0 {
+		if s.inPHP {
+			return ""
+		}
+		if lang := s.tags.next(line); lang != "" {
+			return lang
+		}
+		return s.html
+	}
+
+	s.inPHP = open > closing
+	return ""
+}
+
+// fenceSplitter splits Markdown files into fenced code blocks.
+// Fences belong to Markdown, as blocks of unknown languages.
+type fenceSplitter struct {
+	langs   *DefinedLanguages
+	fence   string // Opening fence of the opened block
+	current string // Language of the opened block
+}
+
+// next returns the language key of a trimmed line.
+func (s *fenceSplitter) next(line string) string {
+	if s.fence != "" {
+		if strings.HasPrefix(line, s.fence) && strings.Trim(line, s.fence[:1]) == "" {
+			s.fence, s.current = "", ""
+			return ""
+		}
+		return s.current
+	}
+
+	for _, c := range []string{"`", "~"} {
+		if !strings.HasPrefix(line, c+c+c) {
+			continue
+		}
+		info := strings.TrimLeft(line, c)
+		s.fence = line[:len(line)-len(info)]
+
+		info = strings.Trim(strings.TrimSpace(info), "{}.")
+		if fields := strings.FieldsFunc(info, func(r rune) bool {
+			return r == ' ' || r == ',' || r == '{' || r == '}'
+		}); len(fields) > 0 {
+			s.current = s.langs.embeddedLanguage(strings.TrimPrefix(fields[0], "."))
+		}
+		if s.current == "Markdown" {
+			s.current = ""
+		}
+		return ""
+	}
+
+	return ""
+}
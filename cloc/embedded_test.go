@@ -0,0 +1,129 @@
+package cloc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// embeddedCounts returns code and comments counts of a file and of its
+// embedded languages.
+func embeddedCounts(f *File) string {
+	s := fmt.Sprintf("%s: %d/%d", f.Language, f.Code, f.Comments)
+	for _, e := range f.Embedded {
+		s += fmt.Sprintf(", %s: %d/%d", e.Language, e.Code, e.Comments)
+	}
+	return s
+}
+
+func TestEmbeddedSplitters(t *testing.T) {
+	tests := []struct {
+		file string
+		lang string
+		want string // code/comments of the file, then of its embedded languages
+	}{
+		{"component.vue", "Vue", "Vue: 13/3, JavaScript: 5/1, CSS: 1/1"},
+		{"page.php", "PHP", "PHP: 15/3, HTML: 10/0, CSS: 1/1, JavaScript: 1/1"},
+		{"guide.md", "Markdown", "Markdown: 11/2, Bourne Shell: 1/1, Go: 1/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			content, err := ioutil.ReadFile(filepath.Join("testdata", "embedded", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := embeddedCounts(analyzeSource(t, tt.lang, string(content))); got != tt.want {
+				t.Errorf("counts = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedPolicies(t *testing.T) {
+	tests := []struct {
+		policy    string
+		languages map[string]string // files: code/comments
+		files     map[string]string // code/comments
+	}{
+		{
+			EmbeddedRollup,
+			map[string]string{
+				"Vue":      "1: 13/3",
+				"PHP":      "1: 15/3",
+				"Markdown": "1: 11/2",
+			},
+			map[string]string{
+				"component.vue": "13/3",
+				"page.php":      "15/3",
+				"guide.md":      "11/2",
+			},
+		},
+		{
+			EmbeddedSeparate,
+			map[string]string{
+				"Vue":          "1: 7/1",
+				"PHP":          "1: 3/1",
+				"Markdown":     "1: 9/0",
+				"HTML":         "1: 10/0",
+				"CSS":          "2: 2/2",
+				"JavaScript":   "2: 6/2",
+				"Bourne Shell": "1: 1/1",
+				"Go":           "1: 1/1",
+			},
+			map[string]string{
+				"component.vue":              "7/1",
+				"component.vue (JavaScript)": "5/1",
+				"component.vue (CSS)":        "1/1",
+				"page.php":                   "3/1",
+				"page.php (HTML)":            "10/0",
+				"page.php (CSS)":             "1/1",
+				"page.php (JavaScript)":      "1/1",
+				"guide.md":                   "9/0",
+				"guide.md (Bourne Shell)":    "1/1",
+				"guide.md (Go)":              "1/1",
+			},
+		},
+	}
+
+	root := filepath.Join("testdata", "embedded")
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			opts := NewOptions()
+			opts.Embedded = tt.policy
+			opts.ByFile = true
+
+			result, err := NewProcessor(NewDefinedLanguages(), opts, []string{root}).AnalyzeContext(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			languages := make(map[string]string)
+			for _, l := range result.Languages {
+				languages[l.Name] = fmt.Sprintf("%d: %d/%d", l.Total, l.Code, l.Comments)
+			}
+			if fmt.Sprint(languages) != fmt.Sprint(tt.languages) {
+				t.Errorf("languages = %v, want %v", languages, tt.languages)
+			}
+
+			files := make(map[string]string)
+			for name, f := range result.Files {
+				rel, _ := filepath.Rel(root, name)
+				files[rel] = fmt.Sprintf("%d/%d", f.Code, f.Comments)
+			}
+			if fmt.Sprint(files) != fmt.Sprint(tt.files) {
+				t.Errorf("files = %v, want %v", files, tt.files)
+			}
+
+			// Each file is counted once in the total
+			// --------------------------------------
+			if result.Total.Total != 3 || result.Total.Code != 39 || result.Total.Comments != 8 {
+				t.Errorf("total = %d files, code: %d, comments: %d; want 3 files, code: 39, comments: 8",
+					result.Total.Total, result.Total.Code, result.Total.Comments)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package cloc
+
+import "fmt"
+
+// Phases in which a file error can occur.
+const (
+	PhaseWalk   = "walk"
+	PhaseIgnore = "ignore"
+	PhaseRead   = "read"
+)
+
+// FileError represents an error which occurred during a file analysis.
+type FileError struct {
+	Path    string `xml:"path,attr" json:"path"`
+	Phase   string `xml:"phase,attr" json:"phase"`
+	Message string `xml:"message,attr" json:"error"`
+	Err     error  `xml:"-" json:"-"`
+}
+
+// newFileError returns a pointer to FileError.
+func newFileError(path, phase string, err error) *FileError {
+	return &FileError{
+		Path:    path,
+		Phase:   phase,
+		Message: err.Error(),
+		Err:     err,
+	}
+}
+
+// Error returns the error message.
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Phase, e.Path, e.Message)
+}
+
+// Unwrap returns the underlying error.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
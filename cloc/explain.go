@@ -0,0 +1,283 @@
+package cloc
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Ways a language is found
+const (
+	ClassifiedByEnry      = "enry"
+	ClassifiedByFilename  = "filename"
+	ClassifiedByShebang   = "shebang"
+	ClassifiedByExtension = "extension"
+)
+
+// Reasons why a file is skipped
+const (
+	SkippedIgnored         = "ignored"
+	SkippedVCS             = "vcs directory"
+	SkippedNotMatchDir     = "directory matches --not-match-dir"
+	SkippedMatchDir        = "directory does not match --match-dir"
+	SkippedUnknownLanguage = "unknown language"
+	SkippedExcludedExt     = "excluded extension"
+	SkippedNotIncludedLang = "language not included"
+	SkippedDuplicate       = "duplicate"
+)
+
+// Classification explains how the language of a file has been found.
+type Classification struct {
+	By    string `json:"by"`    // enry, filename, shebang or extension
+	Value string `json:"value"` // enry language, file name, interpreter or extension
+}
+
+// ExplainedLine represents an analyzed line and its kind.
+type ExplainedLine struct {
+	Number   int32  `json:"number"`
+	Language string `json:"language,omitempty"` // Embedded language (empty for the file language)
+	Kind     string `json:"kind"`
+	Text     string `json:"text"`
+}
+
+// Explanation explains the analysis of a file.
+type Explanation struct {
+	Path           string           `json:"path"`
+	Language       string           `json:"language,omitempty"`
+	Classification *Classification  `json:"classification,omitempty"`
+	Skipped        string           `json:"skipped,omitempty"`
+	DuplicateOf    string           `json:"duplicateOf,omitempty"`
+	File           *File            `json:"file,omitempty"`
+	Lines          []*ExplainedLine `json:"lines,omitempty"`
+}
+
+// Explain explains the analysis of a single file: how its language is found,
+// why it is skipped (if it is) and the kind of each of its lines.
+// The processor paths are the analyzed trees the file belongs to: they are used
+// to check ignore files, exclude patterns and duplicates. Without paths, the
+// file directory is used. The first file in walk order is the one kept
+// among duplicates.
+func (p *Processor) Explain(ctx context.Context, path string) (*Explanation, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	sf, err := openSourceFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer sf.Close()
+
+	e := &Explanation{Path: path}
+
+	// Language
+	// --------
+	lang, c, ok := p.langs.getLanguage(path, sf.head, p.opts)
+	if c.By != "" {
+		e.Classification = &c
+	}
+	def, isDefined := p.langs.Langs[lang]
+	if !ok || !isDefined {
+		e.Skipped = SkippedUnknownLanguage
+		return e, nil
+	}
+	e.Language = def.Name
+
+	// Walk and options checks
+	// -----------------------
+	root, err := p.explainRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	e.Skipped, err = p.explainSkipped(root, path, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lines
+	// -----
+	e.File = NewFile(path, def.Name)
+	e.File.Size = info.Size()
+	e.Lines = make([]*ExplainedLine, 0)
+	e.File.onLine = func(number int32, language, kind, line string) {
+		if int(number) <= len(e.Lines) {
+			e.Lines[number-1].Kind = kind
+			return
+		}
+		e.Lines = append(e.Lines, &ExplainedLine{Number: number, Language: language, Kind: kind, Text: line})
+	}
+	h := md5.New()
+	if err := e.File.analyze(newLineReader(sf.reader, h), def.newCounter(), p.langs, p.opts); err != nil {
+		return nil, err
+	}
+
+	// Duplicates
+	// ----------
+	if e.Skipped == "" && !p.opts.SkipDuplicated {
+		e.DuplicateOf, err = p.findDuplicate(ctx, path, info.Size(), md5Sum(h))
+		if err != nil {
+			return nil, err
+		}
+		if e.DuplicateOf != "" {
+			e.Skipped = SkippedDuplicate
+		}
+	}
+
+	return e, nil
+}
+
+// explainRoot returns the processor path containing the file
+// (the file directory if there is none).
+func (p *Processor) explainRoot(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, root := range p.paths {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return "", err
+		}
+		if absRoot == absPath {
+			return filepath.Dir(root), nil
+		}
+		if rel, err := filepath.Rel(absRoot, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+			return root, nil
+		}
+	}
+
+	return filepath.Dir(path), nil
+}
+
+// explainSkipped returns the reason why the walker or the options skip the
+// file, or an empty string if the file is analyzed.
+// Duplicates are not checked.
+func (p *Processor) explainSkipped(root, path, lang string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Ignore files and exclude patterns
+	// ---------------------------------
+	if !p.opts.NoIgnore || len(p.opts.Exclude) > 0 {
+		ignore, err := newIgnoreMatcher(absRoot, p.opts.Exclude, !p.opts.NoIgnore)
+		if err != nil {
+			return "", err
+		}
+
+		dirs := make([]string, 0)
+		for dir := filepath.Dir(absPath); dir != absRoot && len(dir) > len(absRoot); dir = filepath.Dir(dir) {
+			dirs = append(dirs, dir)
+		}
+		if err := ignore.loadDir(absRoot); err != nil {
+			return "", err
+		}
+		for i := len(dirs) - 1; i >= 0; i-- {
+			if ignore.match(dirs[i], true) {
+				return SkippedIgnored, nil
+			}
+			if err := ignore.loadDir(dirs[i]); err != nil {
+				return "", err
+			}
+		}
+		if ignore.match(absPath, false) {
+			return SkippedIgnored, nil
+		}
+	}
+
+	if reason, ok := isLanguageAnalysable(path, isVCSDir(root), p.opts); !ok {
+		return reason, nil
+	}
+
+	if reason, ok := checkFileOptions(lang, p.opts); !ok {
+		return reason, nil
+	}
+
+	return "", nil
+}
+
+// findDuplicate returns the first file with the same content (md5sum) found
+// before the file when walking processor paths.
+func (p *Processor) findDuplicate(ctx context.Context, path string, size int64, sum [md5.Size]byte) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// Files are walked sequentially by a single consumer
+	// ---------------------------------------------------
+	walkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobsChan := make(chan fileJob)
+	walkDone := make(chan error, 1)
+	go func() {
+		_, err := p.walk(walkCtx, jobsChan)
+		close(jobsChan)
+		walkDone <- err
+	}()
+
+	duplicate := ""
+	done := false
+	for job := range jobsChan {
+		if done {
+			continue
+		}
+		if absJob, err := filepath.Abs(job.path); err == nil && absJob == absPath {
+			done = true
+			cancel()
+			continue
+		}
+		if job.size != size {
+			continue
+		}
+
+		lang, jobSum, err := p.sumFile(ctx, job.path)
+		if err != nil || jobSum != sum {
+			continue
+		}
+		if _, isDefined := p.langs.Langs[lang]; !isDefined {
+			continue
+		}
+		if _, ok := checkFileOptions(lang, p.opts); ok {
+			duplicate = job.path
+			done = true
+			cancel()
+		}
+	}
+
+	if err := <-walkDone; err != nil && err != context.Canceled {
+		return "", err
+	}
+	return duplicate, ctx.Err()
+}
+
+// sumFile returns the language key of a file (empty if it is unknown) and
+// its md5sum.
+func (p *Processor) sumFile(ctx context.Context, path string) (string, [md5.Size]byte, error) {
+	sf, err := openSourceFile(ctx, path)
+	if err != nil {
+		return "", [md5.Size]byte{}, err
+	}
+	defer sf.Close()
+
+	lang, _, _ := p.langs.getLanguage(path, sf.head, p.opts)
+	h := md5.New()
+	if _, err := io.Copy(h, sf.reader); err != nil {
+		return "", [md5.Size]byte{}, err
+	}
+	return lang, md5Sum(h), nil
+}
@@ -2,7 +2,6 @@ package cloc
 
 import (
 	"fmt"
-	"io/ioutil"
 	"path/filepath"
 	"strings"
 
@@ -122,7 +121,7 @@ var (
 		"lds":         "LD Script",
 		"less":        "LESS",
 		"Objective-C": "Objective-C", // deplicated Obj-C/Matlab/Mercury
-		"Matlab":      "MATLAB",      // both use ext '.m'
+		"MATLAB":      "MATLAB",      // both use ext '.m'
 		"Mercury":     "Mercury",     // use ext '.m'
 		"md":          "Markdown",
 		"markdown":    "Markdown",
@@ -200,6 +199,7 @@ var (
 		"csproj":      "MSBuild script",
 		"vcproj":      "MSBuild script",
 		"vim":         "VimL",
+		"svelte":      "Svelte",
 		"vue":         "Vue",
 		"xml":         "XML",
 		"XML":         "XML",
@@ -214,59 +214,116 @@ var (
 		"zig":         "Zig",
 		"zsh":         "Zsh",
 	}
+
+	// ambiguousExtensions lists extensions shared by several languages with
+	// the names enry can classify them as (keys of Extensions).
+	ambiguousExtensions = map[string][]string{
+		"fs": {"F#", "GLSL"},
+		"m":  {"Objective-C", "MATLAB", "Mercury"},
+		"r":  {"R", "Rebol"},
+		"ts": {"TypeScript", "XML"},
+		"v":  {"Coq", "Verilog"},
+	}
+
+	// filenameToLanguage lists languages of special file names.
+	// Lower case names match any case. An empty language skips the file.
+	filenameToLanguage = map[string]string{
+		"meson.build":       "Meson",
+		"meson_options.txt": "Meson",
+		"CMakeLists.txt":    "CMake",
+		"configure.ac":      "M4",
+		"Makefile.am":       "Makefile",
+		"build.xml":         "Ant",
+		"pom.xml":           "Maven",
+		"makefile":          "Makefile",
+		"nukefile":          "Nu",
+		"rebar":             "",
+	}
 )
 
-// getExtension returns file extension from a path with options contraints.
-func getExtension(path string, opts *Options) (ext string, ok bool) {
-	ext = filepath.Ext(path)
-	base := filepath.Base(path)
+// MapExtension maps a file name extension to a language.
+// Mapped extensions are never classified by content, even if they are
+// ambiguous.
+func (d *DefinedLanguages) MapExtension(ext, lang string) {
+	ext = strings.TrimPrefix(ext, ".")
+	d.Extensions[ext] = lang
+	if d.mapped == nil {
+		d.mapped = make(map[string]struct{})
+	}
+	d.mapped[ext] = struct{}{}
+}
 
-	switch ext {
-	case ".m", ".v", ".fs", ".r", ".ts":
-		content, err := ioutil.ReadFile(path)
-		if err != nil {
-			return "", false
-		}
+// isAmbiguous checks if an extension is shared by several languages and not
+// explicitly mapped to one of them.
+func (d *DefinedLanguages) isAmbiguous(ext string) bool {
+	_, isMapped := d.mapped[ext]
+	_, ok := ambiguousExtensions[ext]
+	return ok && !isMapped
+}
 
-		lang := enry.GetLanguage(path, content)
-		if opts.Debug {
-			fmt.Printf("path=%v, lang=%v\n", path, lang)
+// AmbiguousExtensions returns extensions shared by several languages
+// (classified by content) with their candidate languages.
+// Extensions explicitly mapped to a language are not ambiguous.
+func (d *DefinedLanguages) AmbiguousExtensions() map[string][]string {
+	exts := make(map[string][]string, len(ambiguousExtensions))
+	for ext, names := range ambiguousExtensions {
+		if !d.isAmbiguous(ext) {
+			continue
+		}
+		for _, name := range names {
+			if lang, ok := d.Extensions[name]; ok {
+				exts[ext] = append(exts[ext], lang)
+			}
 		}
-		return lang, true
 	}
+	return exts
+}
 
-	switch base {
-	case "meson.build", "meson_options.txt":
-		return "meson", true
-	case "CMakeLists.txt":
-		return "cmake", true
-	case "configure.ac":
-		return "m4", true
-	case "Makefile.am":
-		return "makefile", true
-	case "build.xml":
-		return "Ant", true
-	case "pom.xml":
-		return "maven", true
+// getLanguage returns the language of a file from its path and its content
+// and the way it has been found. The language is found (in this order) by:
+// - enry for ambiguous extensions (unless they are explicitly mapped),
+// - file name,
+// - shebang interpreter,
+// - file name extension.
+func (d *DefinedLanguages) getLanguage(path string, content []byte, opts *Options) (lang string, c Classification, ok bool) {
+	ext := filepath.Ext(path)
+	base := filepath.Base(path)
+
+	if d.isAmbiguous(strings.TrimPrefix(ext, ".")) {
+		enryLang := enry.GetLanguage(path, content)
+		if opts.Debug {
+			fmt.Printf("path=%v, lang=%v\n", path, enryLang)
+		}
+		lang, ok = d.Extensions[enryLang]
+		return lang, Classification{By: ClassifiedByEnry, Value: enryLang}, ok
 	}
 
-	switch strings.ToLower(base) {
-	case "makefile":
-		return "makefile", true
-	case "nukefile":
-		return "nu", true
-	case "rebar": // skip
-		return "", false
+	// File name
+	// ---------
+	for _, name := range []string{base, strings.ToLower(base)} {
+		if lang, ok := d.Filenames[name]; ok {
+			return lang, Classification{By: ClassifiedByFilename, Value: name}, lang != ""
+		}
 	}
 
-	shebangLang, ok := getExtensionByShebang(path)
-	if ok {
-		return shebangLang, true
+	// Shebang
+	// -------
+	if interpreter, ok := getInterpreterByShebang(content); ok {
+		c = Classification{By: ClassifiedByShebang, Value: interpreter}
+		if lang, ok := d.Interpreters[interpreter]; ok {
+			return lang, c, true
+		}
+		if lang, ok := d.Extensions[interpreter]; ok {
+			return lang, c, true
+		}
 	}
 
+	// Extension
+	// ---------
 	if len(ext) >= 2 {
-		return ext[1:], true
+		lang, ok = d.Extensions[ext[1:]]
+		return lang, Classification{By: ClassifiedByExtension, Value: ext[1:]}, ok
 	}
 
-	return ext, ok
+	return lang, Classification{}, ok
 }
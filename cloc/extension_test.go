@@ -0,0 +1,40 @@
+package cloc
+
+import "testing"
+
+func TestMappedAmbiguousExtensions(t *testing.T) {
+	langs := NewDefinedLanguages()
+	if err := langs.Register(LanguageDefinition{Name: "DSL", LineComments: []string{"--"}, Extensions: []string{"ts"}}); err != nil {
+		t.Fatal(err)
+	}
+	langs.MapExtension(".m", "MATLAB")
+
+	tests := []struct {
+		path    string
+		content string
+		lang    string
+		by      string
+	}{
+		{"a.ts", "let a: number = 1;\n", "DSL", ClassifiedByExtension},
+		{"a.m", "#import <Foundation/Foundation.h>\n@interface A : NSObject\n@end\n", "MATLAB", ClassifiedByExtension},
+		{"a.v", "module m;\nendmodule\n", "Verilog", ClassifiedByEnry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			lang, c, ok := langs.getLanguage(tt.path, []byte(tt.content), NewOptions())
+			if !ok || lang != tt.lang || c.By != tt.by {
+				t.Errorf("language = %s (by %s, %v), want %s (by %s)", lang, c.By, ok, tt.lang, tt.by)
+			}
+		})
+	}
+
+	ambiguous := langs.AmbiguousExtensions()
+	for _, ext := range []string{"ts", "m"} {
+		if _, ok := ambiguous[ext]; ok {
+			t.Errorf("mapped extension %s is still ambiguous", ext)
+		}
+	}
+	if _, ok := ambiguous["v"]; !ok {
+		t.Error("extension v is not ambiguous")
+	}
+}
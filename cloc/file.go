@@ -2,12 +2,11 @@ package cloc
 
 import (
 	"bufio"
-	"crypto/md5"
+	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"strings"
-	"sync"
 	"unicode"
 )
 
@@ -18,27 +17,34 @@ type File struct {
 	Language string `xml:"language,attr" json:"language"`
 	Code     int32  `xml:"code,attr" json:"code"`
 	Comments int32  `xml:"comment,attr" json:"comment"`
+	Docs     int32  `xml:"docs,attr" json:"doc"`    // Documentation comments (included in Comments)
+	Mixed    int32  `xml:"mixed,attr" json:"mixed"` // Code lines with a trailing comment
 	Blanks   int32  `xml:"blank,attr" json:"blank"`
 	Lines    int32  `xml:"lines,attr" json:"lines"`
-}
+	Minified bool   `xml:"minified,attr,omitempty" json:"minified,omitempty"`
 
-var bsPool = sync.Pool{
-	New: func() interface{} {
-		b := make([]byte, 0, 128*1024)
-		return &b
-	},
-}
+	// Embedded languages blocks (HTML scripts, Markdown code blocks...)
+	Embedded []*File `xml:"-" json:"embedded,omitempty"`
 
-// getByteSlice returns an array of bytes.
-func getByteSlice() *[]byte {
-	return bsPool.Get().(*[]byte)
-}
+	// onLine is called with the language (empty for the file language) and
+	// the kind of each analyzed line (explain mode).
+	// It can be called again for an already analyzed line to change its kind
+	// (line is then empty).
+	onLine func(number int32, language, kind, line string)
 
-// putByteSlice puts an array of bytes in the pool.
-func putByteSlice(bs *[]byte) {
-	bsPool.Put(bs)
+	// lineLanguage is the embedded language of the current line.
+	lineLanguage string
 }
 
+// Kinds of lines
+const (
+	LineBlank   = "blank"
+	LineComment = "comment"
+	LineDoc     = "doc"
+	LineMixed   = "mixed"
+	LineCode    = "code"
+)
+
 // NewFile returns a pointer to File.
 func NewFile(name, language string) *File {
 	return &File{
@@ -47,23 +53,67 @@ func NewFile(name, language string) *File {
 	}
 }
 
-// analyze analyze a file.
-func (f *File) analyze(language *Language, opts *Options) {
-	// Open file
-	// ---------
-	file, err := os.Open(f.Name)
+// counters returns a copy of the file counters.
+// The name is only kept for minified files (they are reported) and embedded
+// languages files only if embedded is true.
+func (f *File) counters(embedded bool) *File {
+	c := &File{
+		Language: f.Language,
+		Size:     f.Size,
+		Code:     f.Code,
+		Comments: f.Comments,
+		Docs:     f.Docs,
+		Mixed:    f.Mixed,
+		Blanks:   f.Blanks,
+		Lines:    f.Lines,
+		Minified: f.Minified,
+	}
+	if f.Minified {
+		c.Name = f.Name
+	}
+	if embedded {
+		c.Embedded = f.Embedded
+	}
+	return c
+}
+
+// sourceFile is a file to analyze, read through a pooled buffer.
+type sourceFile struct {
+	file   *os.File
+	reader *bufio.Reader
+	head   []byte // Beginning of the file (language detection), valid until the first read
+}
+
+// openSourceFile opens a file to analyze and peeks its first sniffLength bytes.
+// Reading stops when ctx is done.
+func openSourceFile(ctx context.Context, path string) (*sourceFile, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return
+		return nil, err
 	}
-	defer file.Close()
 
-	// File size
-	// ---------
-	fileInfo, _ := file.Stat()
-	if err == nil {
-		f.Size = fileInfo.Size()
+	reader := getReader(&ctxReader{ctx: ctx, r: file})
+	head, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF {
+		putReader(reader)
+		file.Close()
+		return nil, err
 	}
 
+	return &sourceFile{file: file, reader: reader, head: head}, nil
+}
+
+// Close closes the file and puts its buffer back in the pool.
+func (sf *sourceFile) Close() error {
+	putReader(sf.reader)
+	return sf.file.Close()
+}
+
+// analyze analyze a file content.
+// If langs is not nil, blocks of embedded languages are analyzed with
+// their own languages.
+// It returns the reading error which stopped the analysis, if any.
+func (f *File) analyze(reader *lineReader, language *Language, langs *DefinedLanguages, opts *Options) error {
 	// Debug mode
 	// ----------
 	if opts.Debug {
@@ -72,134 +122,217 @@ func (f *File) analyze(language *Language, opts *Options) {
 
 	// File analysis
 	// -------------
-	f.read(file, language, opts)
+	return f.read(reader, language, langs, opts)
 }
 
-// read reads file to analyze.
-func (f *File) read(file *os.File, language *Language, opts *Options) {
-	// Buffer creation
-	// ---------------
-	buf := getByteSlice()
-	defer putByteSlice(buf)
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(*buf, 1024*1024)
+// segment is the part of a file written in a language.
+type segment struct {
+	file     *File // Embedded languages counters (nil for the file language)
+	language *Language
+	scanner  *lineScanner
+	inGroup  bool // Last top-level line of code begins a group of documented members
+}
 
-	isFirstLine := true
-	inComments := [][2]string{}
+// read reads file lines to analyze.
+// Lines longer than the reader buffer are scanned chunk by chunk: only their
+// first chunk is used to find the language of the line and is traced.
+// It returns the reading error which stopped the analysis, if any.
+func (f *File) read(reader *lineReader, language *Language, langs *DefinedLanguages, opts *Options) error {
+	// Embedded languages
+	// ------------------
+	host := &segment{language: language, scanner: newLineScanner(language)}
+	seg := host
+	var splitter embeddedSplitter
+	segments := make(map[string]*segment)
+	if langs != nil {
+		splitter = langs.newEmbeddedSplitter(language.Name)
+	}
+	// Comments lines preceding a declaration documented by them
+	pendingDocs := make([]int32, 0)
 
 	// Lines
 	// -----
-	for scanner.Scan() {
+	for {
+		b, last, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
 		f.Lines++
-		lineOrg := scanner.Text()
+		isFirstLine := f.Lines == 1
+		length := len(b)
+
+		lineOrg := string(b)
 		line := strings.TrimSpace(lineOrg)
 
-		if len(strings.TrimSpace(line)) == 0 {
-			f.onBlank(opts, len(inComments) > 0, line, lineOrg)
+		// Language of the line
+		// --------------------
+		if splitter != nil {
+			next := host
+			if lang := splitter.next(line); lang != "" && lang != language.Name {
+				next = f.embeddedSegment(segments, lang, langs)
+			}
+			if next != seg {
+				pendingDocs = pendingDocs[:0]
+				seg = next
+			}
+			if seg.file != nil {
+				seg.file.Lines++
+				f.lineLanguage = seg.file.Language
+			} else {
+				f.lineLanguage = ""
+			}
+		}
+
+		if len(line) == 0 && last {
+			pendingDocs = pendingDocs[:0]
+			f.onKind(opts, seg, LineBlank, false, line, lineOrg)
 			continue
 		}
 
 		// shebang line is 'code'
 		// ----------------------
-		if isFirstLine && strings.HasPrefix(line, "#!") {
-			f.onCode(opts, len(inComments) > 0, line, lineOrg)
-			isFirstLine = false
+		if isFirstLine && last && strings.HasPrefix(line, "#!") {
+			f.onKind(opts, seg, LineCode, false, line, lineOrg)
 			continue
 		}
 
-		// Single comments
-		// ---------------
-		if len(inComments) == 0 {
+		if isFirstLine {
+			line = trimBOM(line)
+		}
+
+		// Code and comments
+		// -----------------
+		var code, comment, doc bool
+		if last {
+			code, comment, doc = seg.scanner.scan(line)
+		} else {
+			head := strings.TrimLeftFunc(lineOrg, unicode.IsSpace)
 			if isFirstLine {
-				line = trimBOM(line)
+				head = trimBOM(head)
 			}
-
-			stop := false
-			inComments, stop = f.analyzeSingleComments(line, lineOrg, language, opts, inComments)
-			if stop {
-				continue
+			n, err := scanLongLine(reader, seg.scanner, head)
+			if err != nil {
+				return err
 			}
+			length += n
+			code, comment, doc = seg.scanner.endLine()
 		}
 
-		if len(inComments) == 0 && !containsComment(line, language.multiLines) {
-			f.onCode(opts, len(inComments) > 0, line, lineOrg)
-			continue
+		// Minified or generated file
+		// --------------------------
+		if opts.MinifiedLineLength > 0 && length > opts.MinifiedLineLength {
+			f.Minified = true
 		}
 
-		// Multi comments
-		// --------------
-		if len(language.multiLines) == 1 && len(language.multiLines[0]) == 2 && language.multiLines[0][0] == "" {
-			f.onCode(opts, len(inComments) > 0, line, lineOrg)
+		if !code && !comment {
+			pendingDocs = pendingDocs[:0]
+			f.onKind(opts, seg, LineBlank, false, line, lineOrg)
+			continue
+		}
+		if !code {
+			f.onKind(opts, seg, LineComment, doc, line, lineOrg)
+			if !doc && seg.language.docs.declaration != nil {
+				pendingDocs = append(pendingDocs, f.Lines)
+			}
 			continue
 		}
-		inComments = f.analyzeMultiComments(line, lineOrg, language, opts, inComments)
-	}
-}
 
-// analyzeSingleComments analyzes single comments in file line.
-// It returns inComments array and a bool. If it equals to true, the program goes to the next line.
-func (f *File) analyzeSingleComments(line, lineOrg string, lang *Language, opts *Options, inComments [][2]string) ([][2]string, bool) {
-singleloop:
-	for _, singleComment := range lang.lineComments {
-		if strings.HasPrefix(line, singleComment) {
-			// Check if single comment is a prefix of multi comment
-			for _, ml := range lang.multiLines {
-				if ml[0] != "" && strings.HasPrefix(line, ml[0]) {
-					break singleloop
+		if docs := &seg.language.docs; docs.declaration != nil {
+			indented := isIndented(lineOrg)
+			if len(pendingDocs) > 0 && docs.documents(line, indented, seg.inGroup) {
+				f.onDocs(pendingDocs)
+				if seg.file != nil {
+					seg.file.onDocs(pendingDocs)
 				}
 			}
-			f.onComment(opts, len(inComments) > 0, line, lineOrg)
-			return inComments, true
+			if !indented {
+				seg.inGroup = docs.group != nil && docs.group.MatchString(line)
+			}
+		}
+		pendingDocs = pendingDocs[:0]
+		if comment {
+			f.onKind(opts, seg, LineMixed, doc, line, lineOrg)
+		} else {
+			f.onKind(opts, seg, LineCode, doc, line, lineOrg)
 		}
 	}
 
-	if len(lang.multiLines) == 0 {
-		f.onCode(opts, len(inComments) > 0, line, lineOrg)
-		return inComments, true
-	}
+	return nil
+}
 
-	return inComments, false
+// scanLongLine scans a line longer than the reader buffer chunk by chunk,
+// beginning with its first chunk (head), and returns the length of the
+// following chunks.
+func scanLongLine(reader *lineReader, scanner *lineScanner, head string) (int, error) {
+	scanner.beginLine(head)
+	scanner.scanChunk(head, false)
+
+	length := 0
+	for {
+		chunk, last, err := reader.next()
+		if err != nil {
+			return length, err
+		}
+		length += len(chunk)
+		scanner.scanChunk(string(chunk), last)
+		if last {
+			return length, nil
+		}
+	}
 }
 
-// analyzeMultiComments analyzes multi comments in file line and return inComments array with updates.
-func (f *File) analyzeMultiComments(line, lineOrg string, lang *Language, opts *Options, inComments [][2]string) [][2]string {
-	isCode := false
-	lenLine := len(line)
-	for pos := 0; pos < lenLine; pos++ {
-		for _, ml := range lang.multiLines {
-			begin, end := ml[0], ml[1]
-			lenBegin := len(begin)
-
-			if pos+lenBegin <= lenLine && strings.HasPrefix(line[pos:], begin) && (begin != end || len(inComments) == 0) {
-				pos += lenBegin
-				inComments = append(inComments, [2]string{begin, end})
-				continue
-			}
+// embeddedSegment returns the segment of an embedded language.
+// Embedded files are added to f.Embedded in order of appearance.
+func (f *File) embeddedSegment(segments map[string]*segment, lang string, langs *DefinedLanguages) *segment {
+	if seg, ok := segments[lang]; ok {
+		return seg
+	}
 
-			if n := len(inComments); n > 0 {
-				last := inComments[n-1]
-				if pos+len(last[1]) <= lenLine && strings.HasPrefix(line[pos:], last[1]) {
-					inComments = inComments[:n-1]
-					pos += len(last[1])
-				}
-			} else if pos < lenLine && !unicode.IsSpace(nextRune(line[pos:])) {
-				isCode = true
-			}
-		}
+	def := langs.Langs[lang]
+	seg := &segment{
+		file:     NewFile(f.Name, def.Name),
+		language: def,
+		scanner:  newLineScanner(def),
 	}
+	segments[lang] = seg
+	f.Embedded = append(f.Embedded, seg.file)
+
+	return seg
+}
 
-	if isCode {
-		f.onCode(opts, len(inComments) > 0, line, lineOrg)
-	} else {
-		f.onComment(opts, len(inComments) > 0, line, lineOrg)
+// onKind updates File informations (and embedded language ones) for a line kind.
+func (f *File) onKind(opts *Options, seg *segment, kind string, isDoc bool, line, lineOrg string) {
+	isInComments := seg.scanner.inComments()
+	f.onLineKind(opts, isInComments, kind, isDoc, line, lineOrg)
+	if seg.file != nil {
+		embeddedOpts := *opts
+		embeddedOpts.Debug = false
+		seg.file.onLineKind(&embeddedOpts, isInComments, kind, isDoc, line, lineOrg)
 	}
+}
 
-	return inComments
+// onLineKind updates File informations for a line kind.
+func (f *File) onLineKind(opts *Options, isInComments bool, kind string, isDoc bool, line, lineOrg string) {
+	switch kind {
+	case LineBlank:
+		f.onBlank(opts, isInComments, line, lineOrg)
+	case LineComment:
+		f.onComment(opts, isInComments, isDoc, line, lineOrg)
+	case LineMixed:
+		f.onMixed(opts, isInComments, isDoc, line, lineOrg)
+	default:
+		f.onCode(opts, isInComments, line, lineOrg)
+	}
 }
 
 // onBlank update File blanks informations.
 func (f *File) onBlank(opts *Options, isInComments bool, line, lineOrg string) {
 	f.Blanks++
+	f.trace(LineBlank, lineOrg)
 	if opts.Debug {
 		fmt.Printf("[BLNK, cd:%d, cm:%d, bk:%d, iscm:%v] %s\n",
 			f.Code, f.Comments, f.Blanks, isInComments, lineOrg)
@@ -207,23 +340,68 @@ func (f *File) onBlank(opts *Options, isInComments bool, line, lineOrg string) {
 }
 
 // onComment update File comments informations.
-func (f *File) onComment(opts *Options, isInComments bool, line, lineOrg string) {
+func (f *File) onComment(opts *Options, isInComments, isDoc bool, line, lineOrg string) {
 	f.Comments++
+	kind, debugKind := LineComment, "COMM"
+	if isDoc {
+		f.Docs++
+		kind, debugKind = LineDoc, "DOCS"
+	}
+	f.trace(kind, lineOrg)
 	if opts.Debug {
-		fmt.Printf("[COMM, cd:%d, cm:%d, bk:%d, iscm:%v] %s\n",
-			f.Code, f.Comments, f.Blanks, isInComments, lineOrg)
+		fmt.Printf("[%s, cd:%d, cm:%d, bk:%d, iscm:%v] %s\n",
+			debugKind, f.Code, f.Comments, f.Blanks, isInComments, lineOrg)
+	}
+}
+
+// onDocs update File documentation informations with already counted
+// comments lines (lines numbers).
+func (f *File) onDocs(lines []int32) {
+	f.Docs += int32(len(lines))
+	if f.onLine != nil {
+		for _, n := range lines {
+			f.onLine(n, f.lineLanguage, LineDoc, "")
+		}
 	}
 }
 
 // onCode update File code informations.
 func (f *File) onCode(opts *Options, isInComments bool, line, lineOrg string) {
 	f.Code++
+	f.trace(LineCode, lineOrg)
 	if opts.Debug {
 		fmt.Printf("[CODE, cd:%d, cm:%d, bk:%d, iscm:%v] %s\n",
 			f.Code, f.Comments, f.Blanks, isInComments, lineOrg)
 	}
 }
 
+// onMixed update File informations for a line with code and comments.
+// The line is counted as code, comment or both depending on opts.MixedLines.
+func (f *File) onMixed(opts *Options, isInComments, isDoc bool, line, lineOrg string) {
+	f.Mixed++
+	if opts.MixedLines != MixedAsComment {
+		f.Code++
+	}
+	if opts.MixedLines == MixedAsComment || opts.MixedLines == MixedAsBoth {
+		f.Comments++
+		if isDoc {
+			f.Docs++
+		}
+	}
+	f.trace(LineMixed, lineOrg)
+	if opts.Debug {
+		fmt.Printf("[MIXD, cd:%d, cm:%d, bk:%d, iscm:%v] %s\n",
+			f.Code, f.Comments, f.Blanks, isInComments, lineOrg)
+	}
+}
+
+// trace sends the kind of the current line to the onLine callback, if any.
+func (f *File) trace(kind, line string) {
+	if f.onLine != nil {
+		f.onLine(f.Lines, f.lineLanguage, kind, line)
+	}
+}
+
 // isVCSDir checks if directory is a version control system.
 func isVCSDir(path string) bool {
 	if len(path) > 1 && path[0] == os.PathSeparator {
@@ -250,45 +428,3 @@ func trimBOM(line string) string {
 	}
 	return line
 }
-
-// checkMD5Sum checks md5sum for a path and returns true if a file file
-// has ready been added.
-func checkMD5Sum(path string, fileCache map[string]struct{}) (ignore bool) {
-	content, err := ioutil.ReadFile(path)
-	if err != nil {
-		return true
-	}
-
-	// Calculate md5sum
-	// ----------------
-	hash := md5.Sum(content)
-	c := fmt.Sprintf("%x", hash)
-	if _, ok := fileCache[c]; ok {
-		return true
-	}
-
-	fileCache[c] = struct{}{}
-
-	return false
-}
-
-// containsComment checks if a line contains a comment.
-func containsComment(line string, multiLines [][]string) bool {
-	for _, ml := range multiLines {
-		for _, c := range ml {
-			if strings.Contains(line, c) {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-// nextRune returns the next rune.
-// TODO: In goutils
-func nextRune(s string) rune {
-	for _, r := range s {
-		return r
-	}
-	return 0
-}
@@ -0,0 +1,161 @@
+package cloc
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// analyzeSource analyzes a source of a language with default options.
+func analyzeSource(t *testing.T, lang, src string) *File {
+	t.Helper()
+
+	return analyzeSourceWith(t, lang, src, NewOptions())
+}
+
+// analyzeSourceWith analyzes src as a file of language lang with opts.
+func analyzeSourceWith(t *testing.T, lang, src string, opts *Options) *File {
+	t.Helper()
+
+	langs := NewDefinedLanguages()
+	def, ok := langs.Langs[lang]
+	if !ok {
+		t.Fatalf("unknown language %s", lang)
+	}
+
+	reader := getReader(strings.NewReader(src))
+	defer putReader(reader)
+
+	f := NewFile("test", def.Name)
+	if err := f.analyze(newLineReader(reader, nil), def, langs, opts); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestAnalyzeLongLines(t *testing.T) {
+	long := "int a = 1; // " + strings.Repeat("x", 320*1024) + " /* not opened"
+	tests := []struct {
+		name     string
+		src      string
+		code     int32
+		comments int32
+		mixed    int32
+		minified bool
+	}{
+		{"short lines", "int a;\n/* c */\nint b;\n", 2, 1, 0, false},
+		{"block comment after a long line", "int a = \"" + strings.Repeat("x", 320*1024) + "\"; /* start\n still comment */\nint b;\n", 2, 1, 1, true},
+		{"line comment in a long line", long + "\nint b;\n", 2, 0, 1, true},
+		{"CRLF and no final end of line", "int a;\r\n\r\n// c\r\nint b;", 2, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := analyzeSource(t, "C", tt.src)
+			if f.Code != tt.code || f.Comments != tt.comments || f.Mixed != tt.mixed || f.Minified != tt.minified {
+				t.Errorf("code: %d, comments: %d, mixed: %d, minified: %v; want code: %d, comments: %d, mixed: %d, minified: %v",
+					f.Code, f.Comments, f.Mixed, f.Minified, tt.code, tt.comments, tt.mixed, tt.minified)
+			}
+		})
+	}
+}
+
+// repeatReader generates n bytes c, so long lines are never held whole
+// by the tests.
+type repeatReader struct {
+	c byte
+	n int
+}
+
+// Read fills p with c.
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, io.EOF
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	for i := range p {
+		p[i] = r.c
+	}
+	r.n -= len(p)
+	return len(p), nil
+}
+
+// longLineSource returns a reader of a C source whose first line is longer
+// than the line reader buffer, with a block comment opened at its end.
+func longLineSource(length int) io.Reader {
+	return io.MultiReader(
+		strings.NewReader(`char *s = "`),
+		&repeatReader{c: 'x', n: length},
+		strings.NewReader("\"; /* start\n still comment */\nint b;\n"),
+	)
+}
+
+func TestLineReaderChunks(t *testing.T) {
+	length := 10 * lineReaderSize
+	reader := getReader(longLineSource(length))
+	defer putReader(reader)
+
+	h := md5.New()
+	lr := newLineReader(reader, h)
+	var lengths []int
+	lineLength := 0
+	for {
+		chunk, last, err := lr.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunk) > lineReaderSize {
+			t.Fatalf("chunk length = %d, want at most %d", len(chunk), lineReaderSize)
+		}
+		lineLength += len(chunk)
+		if last {
+			lengths = append(lengths, lineLength)
+			lineLength = 0
+		}
+	}
+
+	if want := []int{len(`char *s = "`) + length + len(`"; /* start`), len(" still comment */"), len("int b;")}; fmt.Sprint(lengths) != fmt.Sprint(want) {
+		t.Errorf("lines lengths = %v, want %v", lengths, want)
+	}
+
+	// The whole content is hashed
+	// ---------------------------
+	expected := md5.New()
+	if _, err := io.Copy(expected, longLineSource(length)); err != nil {
+		t.Fatal(err)
+	}
+	if md5Sum(h) != md5Sum(expected) {
+		t.Error("md5sum of read lines differs from the content md5sum")
+	}
+}
+
+func TestAnalyzeLongLineChunks(t *testing.T) {
+	langs := NewDefinedLanguages()
+	def := langs.Langs["C"]
+
+	// The block comment delimiter is moved around the end of the fourth chunk
+	prefix := len(`char *s = "`) + len(`"; `)
+	for offset := -4; offset <= 4; offset++ {
+		t.Run(fmt.Sprintf("offset=%d", offset), func(t *testing.T) {
+			length := 4*lineReaderSize - 1 - prefix + offset
+			reader := getReader(longLineSource(length))
+			defer putReader(reader)
+
+			f := NewFile("test", def.Name)
+			if err := f.analyze(newLineReader(reader, nil), def, langs, NewOptions()); err != nil {
+				t.Fatal(err)
+			}
+			if f.Lines != 3 || f.Code != 2 || f.Comments != 1 || f.Mixed != 1 || !f.Minified {
+				t.Errorf("lines: %d, code: %d, comments: %d, mixed: %d, minified: %v; want lines: 3, code: 2, comments: 1, mixed: 1, minified: true",
+					f.Lines, f.Code, f.Comments, f.Mixed, f.Minified)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package cloc
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFiles lists ignore files read in each directory.
+// Patterns of a file take precedence over patterns of previous files.
+var ignoreFiles = []string{".gitignore", ".ignore", ".gcaignore"}
+
+// ignorePattern is a gitignore pattern.
+type ignorePattern struct {
+	base    string // Directory of the ignore file
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// ignoreMatcher matches paths against gitignore rules.
+type ignoreMatcher struct {
+	global    []*ignorePattern            // Global excludes file and .git/info/exclude
+	dirs      map[string][]*ignorePattern // Patterns by directory
+	excludes  []*ignorePattern            // Options patterns
+	loadFiles bool
+}
+
+// newIgnoreMatcher returns a pointer to an ignoreMatcher for the absolute root path.
+// excludes patterns are relative to root and take precedence over ignore files.
+// If loadFiles is true, it loads global excludes file, .git/info/exclude and
+// ignore files of directories between the repository root and the root path.
+func newIgnoreMatcher(root string, excludes []string, loadFiles bool) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{
+		global:    make([]*ignorePattern, 0),
+		dirs:      make(map[string][]*ignorePattern),
+		excludes:  make([]*ignorePattern, 0, len(excludes)),
+		loadFiles: loadFiles,
+	}
+
+	for _, e := range excludes {
+		if p, ok := newIgnorePattern(e, root); ok {
+			m.excludes = append(m.excludes, p)
+		}
+	}
+	if !loadFiles {
+		return m, nil
+	}
+
+	repoRoot, ok := gitRepositoryRoot(root)
+	base := root
+	if ok {
+		base = repoRoot
+	}
+
+	// Global excludes file and .git/info/exclude
+	// ------------------------------------------
+	for _, file := range []string{
+		globalExcludesFile(),
+		filepath.Join(base, ".git", "info", "exclude"),
+	} {
+		patterns, err := readIgnoreFile(file, base)
+		if err != nil {
+			return nil, err
+		}
+		m.global = append(m.global, patterns...)
+	}
+
+	// Ignore files from repository root to root parent
+	// ------------------------------------------------
+	if ok {
+		dirs := make([]string, 0)
+		for dir := filepath.Dir(root); dir != repoRoot && len(dir) > len(repoRoot); dir = filepath.Dir(dir) {
+			dirs = append(dirs, dir)
+		}
+		if root != repoRoot {
+			dirs = append(dirs, repoRoot)
+		}
+		for i := len(dirs) - 1; i >= 0; i-- {
+			if err := m.loadDir(dirs[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// loadDir loads ignore files of a directory.
+func (m *ignoreMatcher) loadDir(dir string) error {
+	if !m.loadFiles {
+		return nil
+	}
+
+	for _, name := range ignoreFiles {
+		patterns, err := readIgnoreFile(filepath.Join(dir, name), dir)
+		if err != nil {
+			return err
+		}
+		if len(patterns) > 0 {
+			m.dirs[dir] = append(m.dirs[dir], patterns...)
+		}
+	}
+	return nil
+}
+
+// match checks if an absolute path is ignored.
+// The last matching pattern wins and patterns of deeper directories take
+// precedence over patterns of upper directories and global patterns.
+func (m *ignoreMatcher) match(path string, isDir bool) bool {
+	ignored := false
+	check := func(patterns []*ignorePattern) {
+		for _, p := range patterns {
+			if p.match(path, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+
+	check(m.global)
+
+	dirs := make([]string, 0)
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, ok := m.dirs[dir]; ok {
+			dirs = append(dirs, dir)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		check(m.dirs[dirs[i]])
+	}
+
+	check(m.excludes)
+
+	return ignored
+}
+
+// match checks if an absolute path matches the pattern.
+func (p *ignorePattern) match(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	rel, err := filepath.Rel(p.base, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	return p.re.MatchString(filepath.ToSlash(rel))
+}
+
+// readIgnoreFile reads gitignore patterns of a file.
+// Patterns are relative to base directory. A missing file has no pattern.
+func readIgnoreFile(path, base string) ([]*ignorePattern, error) {
+	patterns := make([]*ignorePattern, 0)
+	if path == "" {
+		return patterns, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return patterns, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p, ok := newIgnorePattern(scanner.Text(), base); ok {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return patterns, scanner.Err()
+}
+
+// newIgnorePattern parses a gitignore line and returns the pattern.
+// ok is false if the line has no pattern (blank line, comment or invalid pattern).
+func newIgnorePattern(line, base string) (p *ignorePattern, ok bool) {
+	line = trimIgnoreTrailingSpaces(strings.TrimSuffix(line, "\r"))
+	if line == "" || line[0] == '#' {
+		return nil, false
+	}
+
+	p = &ignorePattern{base: base}
+
+	// Negation
+	// --------
+	if line[0] == '!' {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	// Directory only
+	// --------------
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, false
+	}
+
+	// A pattern without slash matches at any level,
+	// else it is relative to the ignore file directory.
+	// ---------------------------------------------------
+	anchored := strings.Contains(line, "/")
+	expr := globToRegexp(strings.TrimPrefix(line, "/"))
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, false
+	}
+	p.re = re
+
+	return p, true
+}
+
+// globToRegexp converts a gitignore glob to a regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	n := len(glob)
+
+	for i := 0; i < n; i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < n && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				atEnd := i+2 == n || glob[i+2] == '/'
+				switch {
+				case atStart && i+2 == n:
+					// Trailing "/**" or "**": everything
+					sb.WriteString(".*")
+					i++
+				case atStart && atEnd:
+					// "**/": zero or more directories
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				default:
+					// Other consecutive asterisks are regular asterisks
+					sb.WriteString("[^/]*")
+					i++
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			j := i + 1
+			if j < n && (glob[j] == '!' || glob[j] == '^') {
+				j++
+			}
+			if j < n && glob[j] == ']' {
+				j++
+			}
+			for j < n && glob[j] != ']' {
+				j++
+			}
+			if j >= n {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : j]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i = j
+		case '\\':
+			if i+1 < n {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return sb.String()
+}
+
+// trimIgnoreTrailingSpaces trims trailing spaces which are not escaped.
+func trimIgnoreTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// gitRepositoryRoot returns the first parent directory (path included)
+// containing a .git entry.
+func gitRepositoryRoot(path string) (string, bool) {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return "", false
+		}
+	}
+}
+
+// globalExcludesFile returns the path of the git global excludes file
+// (core.excludesFile or $XDG_CONFIG_HOME/git/ignore).
+func globalExcludesFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		xdg = filepath.Join(home, ".config")
+	}
+
+	for _, config := range []string{filepath.Join(home, ".gitconfig"), filepath.Join(xdg, "git", "config")} {
+		if path := gitConfigExcludesFile(config); path != "" {
+			if strings.HasPrefix(path, "~/") {
+				path = filepath.Join(home, path[2:])
+			}
+			return path
+		}
+	}
+
+	return filepath.Join(xdg, "git", "ignore")
+}
+
+// gitConfigExcludesFile returns core.excludesFile value of a git config file.
+func gitConfigExcludesFile(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	inCore := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			section := strings.TrimSpace(strings.Trim(line, "[]"))
+			inCore = strings.EqualFold(section, "core")
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if inCore && len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "excludesfile") {
+			return strings.Trim(strings.TrimSpace(parts[1]), `"`)
+		}
+	}
+
+	return ""
+}
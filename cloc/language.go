@@ -1,9 +1,7 @@
 package cloc
 
 import (
-	"bufio"
 	"bytes"
-	"os"
 	"path/filepath"
 	"regexp"
 	"unicode"
@@ -11,37 +9,67 @@ import (
 
 // Language represents a language with its properties.
 type Language struct {
-	Name         string
-	lineComments []string
-	multiLines   [][]string
-	Files        []string
-	Code         int32
-	Comments     int32
-	Blanks       int32
-	Total        int32
-	Lines        int32
-	Size         int64
+	Name         string     `json:"name"`
+	lineComments []string   `json:"-"`
+	multiLines   [][]string `json:"-"`
+	nested       [][]string // Block comments which can be nested
+	strings      []StringDelimiter
+	docs         docMarkers
+	Code         int32 `json:"code"`
+	Comments     int32 `json:"comment"`
+	Docs         int32 `json:"doc"`
+	Mixed        int32 `json:"mixed"`
+	Blanks       int32 `json:"blank"`
+	Total        int32 `json:"files"`
+	Lines        int32 `json:"lines"`
+	Size         int64 `json:"size"`
+
+	// embeddedFiles are files counted in Total for their embedded blocks only
+	// (separate mode).
+	embeddedFiles int32
 }
 
-// DefinedLanguages represents a map of available Language.
+// DefinedLanguages represents a map of available Language and the way
+// files are associated to them.
 type DefinedLanguages struct {
-	Langs map[string]*Language
+	Langs        map[string]*Language
+	Extensions   map[string]string // File name extension to language
+	Filenames    map[string]string // File name to language (empty language to skip the file)
+	Interpreters map[string]string // Shebang interpreter to language
+
+	// mapped are extensions explicitly mapped to a language (see MapExtension)
+	mapped map[string]struct{}
 }
 
 var (
 	// shebang regex
-	shebangEnvRegex  = regexp.MustCompile(`^#! *(\\S+/env) ([a-zA-Z]+)`)
+	shebangEnvRegex  = regexp.MustCompile(`^#! *(\S+/env) ([a-zA-Z]+)`)
 	shebangLangRegex = regexp.MustCompile(`^#! *[.a-zA-Z/]+/([a-zA-Z]+)`)
 
-	// shebangToExtension converts shebang to extension.
-	shebangToExtension = map[string]string{
-		"gosh":    "scm",
-		"make":    "make",
-		"perl":    "pl",
-		"rc":      "plan9sh",
-		"python":  "py",
-		"ruby":    "rb",
-		"escript": "erl",
+	// shebangToLanguage converts shebang interpreter to language.
+	// Other interpreters are considered as file name extensions.
+	shebangToLanguage = map[string]string{
+		"gosh":    "Scheme",
+		"make":    "Makefile",
+		"perl":    "Perl",
+		"rc":      "Plan9 Shell",
+		"python":  "Python",
+		"ruby":    "Ruby",
+		"escript": "Erlang",
+	}
+
+	// nestedCommentsLanguages lists languages whose block comments can all
+	// be nested.
+	nestedCommentsLanguages = []string{
+		"Coq", "Dart", "Dhall", "Elm", "F#", "Frege", "Haskell", "Idris",
+		"Isabelle", "Kotlin", "Lean", "LISP", "Nim", "OCaml", "Racket", "Rust",
+		"Scala", "Scheme", "Standard ML", "Swift",
+	}
+
+	// nestedComments lists nested block comments of languages whose block
+	// comments cannot all be nested.
+	nestedComments = map[string][][]string{
+		"D": {{"/+", "+/"}},
 	}
 )
 
@@ -51,13 +79,72 @@ func NewLanguage(name string, lineComments []string, multiLines [][]string) *Lan
 		Name:         name,
 		lineComments: lineComments,
 		multiLines:   multiLines,
-		Files:        []string{},
 	}
 }
 
+// newCounter returns a copy of the language properties with empty counters.
+func (l *Language) newCounter() *Language {
+	c := NewLanguage(l.Name, l.lineComments, l.multiLines)
+	c.nested = l.nested
+	c.strings = l.strings
+	c.docs = l.docs
+	return c
+}
+
+// add adds file counters to the language.
+func (l *Language) add(f *File) {
+	l.Total++
+	l.Size += f.Size
+	l.Blanks += f.Blanks
+	l.Code += f.Code
+	l.Comments += f.Comments
+	l.Docs += f.Docs
+	l.Mixed += f.Mixed
+	l.Lines += f.Lines
+}
+
+// addEmbedded moves lines counters of an embedded language file from the
+// file language (l) to the embedded language (e).
+// The file is also counted in the files of the embedded language, but its
+// size stays in the file language.
+func (l *Language) addEmbedded(e *Language, f *File) {
+	e.Total++
+	e.embeddedFiles++
+
+	l.Blanks -= f.Blanks
+	l.Code -= f.Code
+	l.Comments -= f.Comments
+	l.Docs -= f.Docs
+	l.Mixed -= f.Mixed
+	l.Lines -= f.Lines
+
+	e.Blanks += f.Blanks
+	e.Code += f.Code
+	e.Comments += f.Comments
+	e.Docs += f.Docs
+	e.Mixed += f.Mixed
+	e.Lines += f.Lines
+}
+
+// merge adds counters of another language to the language.
+func (l *Language) merge(o *Language) {
+	l.Total += o.Total
+	l.Size += o.Size
+	l.Blanks += o.Blanks
+	l.Code += o.Code
+	l.Comments += o.Comments
+	l.Docs += o.Docs
+	l.Mixed += o.Mixed
+	l.Lines += o.Lines
+	l.embeddedFiles += o.embeddedFiles
+}
+
 // NewDefinedLanguages returns the list of all available languages with their properties.
 func NewDefinedLanguages() *DefinedLanguages {
-	return &DefinedLanguages{
+	d := &DefinedLanguages{
+		Extensions:   copyStringMap(Extensions),
+		Filenames:    copyStringMap(filenameToLanguage),
+		Interpreters: copyStringMap(shebangToLanguage),
 		Langs: map[string]*Language{
 			"ActionScript":        NewLanguage("ActionScript", []string{"//"}, [][]string{{"/*", "*/"}}),
 			"Ada":                 NewLanguage("Ada", []string{"--"}, [][]string{{"", ""}}),
@@ -88,9 +175,9 @@ func NewDefinedLanguages() *DefinedLanguages {
 			"C++ Header":          NewLanguage("C++ Header", []string{"//"}, [][]string{{"/*", "*/"}}),
 			"Crystal":             NewLanguage("Crystal", []string{"#"}, [][]string{{"", ""}}),
 			"CSS":                 NewLanguage("CSS", []string{"//"}, [][]string{{"/*", "*/"}}),
-			"Cython":              NewLanguage("Cython", []string{"#"}, [][]string{{"\"\"\"", "\"\"\""}}),
+			"Cython":              NewLanguage("Cython", []string{"#"}, [][]string{{"", ""}}),
 			"CUDA":                NewLanguage("CUDA", []string{"//"}, [][]string{{"/*", "*/"}}),
-			"D":                   NewLanguage("D", []string{"//"}, [][]string{{"/*", "*/"}}),
+			"D":                   NewLanguage("D", []string{"//"}, [][]string{{"/*", "*/"}, {"/+", "+/"}}),
 			"Dart":                NewLanguage("Dart", []string{"//", "///"}, [][]string{{"/*", "*/"}}),
 			"Dhall":               NewLanguage("Dhall", []string{"--"}, [][]string{{"{-", "-}"}}),
 			"DTrace":              NewLanguage("DTrace", []string{}, [][]string{{"/*", "*/"}}),
@@ -112,7 +199,7 @@ func NewDefinedLanguages() *DefinedLanguages {
 			"FORTRAN Legacy":      NewLanguage("FORTRAN Legacy", []string{"c", "C", "!", "*"}, [][]string{{"", ""}}),
 			"FORTRAN Modern":      NewLanguage("FORTRAN Modern", []string{"!"}, [][]string{{"", ""}}),
 			"Gherkin":             NewLanguage("Gherkin", []string{"#"}, [][]string{{"", ""}}),
-			"GlL":                 NewLanguage("GLSL", []string{"//"}, [][]string{{"/*", "*/"}}),
+			"GLSL":                NewLanguage("GLSL", []string{"//"}, [][]string{{"/*", "*/"}}),
 			"Go":                  NewLanguage("Go", []string{"//"}, [][]string{{"/*", "*/"}}),
 			"Groovy":              NewLanguage("Groovy", []string{"//"}, [][]string{{"/*", "*/"}}),
 			"Haskell":             NewLanguage("Haskell", []string{"--"}, [][]string{{"{-", "-}"}}),
@@ -158,7 +245,7 @@ func NewDefinedLanguages() *DefinedLanguages {
 			"PowerShell":          NewLanguage("PowerShell", []string{"#"}, [][]string{{"<#", "#>"}}),
 			"Polly":               NewLanguage("Polly", []string{"<!--"}, [][]string{{"<!--", "-->"}}),
 			"Protocol Buffers":    NewLanguage("Protocol Buffers", []string{"//"}, [][]string{{"", ""}}),
-			"Python":              NewLanguage("Python", []string{"#"}, [][]string{{"\"\"\"", "\"\"\""}}),
+			"Python":              NewLanguage("Python", []string{"#"}, [][]string{{"", ""}}),
 			"Q":                   NewLanguage("Q", []string{"/ "}, [][]string{{"\\", "/"}, {"/", "\\"}}),
 			"QML":                 NewLanguage("QML", []string{"//"}, [][]string{{"/*", "*/"}}),
 			"R":                   NewLanguage("R", []string{"#"}, [][]string{{"", ""}}),
@@ -180,6 +267,7 @@ func NewDefinedLanguages() *DefinedLanguages {
 			"Bourne Shell":        NewLanguage("Bourne Shell", []string{"#"}, [][]string{{"", ""}}),
 			"Standard ML":         NewLanguage("Standard ML", []string{}, [][]string{{"(*", "*)"}}),
 			"SQL":                 NewLanguage("SQL", []string{"--"}, [][]string{{"/*", "*/"}}),
+			"Svelte":              NewLanguage("Svelte", []string{"<!--"}, [][]string{{"<!--", "-->"}}),
 			"Swift":               NewLanguage("Swift", []string{"//"}, [][]string{{"/*", "*/"}}),
 			"Terra":               NewLanguage("Terra", []string{"--"}, [][]string{{"--[[", "]]"}}),
 			"TeX":                 NewLanguage("TeX", []string{"%"}, [][]string{{"", ""}}),
@@ -206,78 +294,113 @@ func NewDefinedLanguages() *DefinedLanguages {
 			"Zsh":                 NewLanguage("Zsh", []string{"#"}, [][]string{{"", ""}}),
 		},
 	}
+
+	for name, strs := range languageStrings {
+		if lang, ok := d.Langs[name]; ok {
+			lang.strings = strs
+		}
+	}
+	for name, docs := range languageDocs {
+		if lang, ok := d.Langs[name]; ok {
+			lang.docs = docs
+		}
+	}
+	for _, name := range nestedCommentsLanguages {
+		if lang, ok := d.Langs[name]; ok {
+			lang.nested = lang.multiLines
+		}
+	}
+	for name, nested := range nestedComments {
+		if lang, ok := d.Langs[name]; ok {
+			lang.nested = nested
+		}
+	}
+
+	return d
+}
+
+// LineComments returns single line comments delimiters.
+func (l *Language) LineComments() []string {
+	return l.lineComments
+}
+
+// MultiLines returns multi lines comments delimiters (begin and end).
+func (l *Language) MultiLines() [][]string {
+	return l.multiLines
 }
 
-// getShebang returns shebang.
-func getShebang(line string) (shebangLang string, ok bool) {
+// NestedComments returns multi lines comments delimiters which can be nested.
+func (l *Language) NestedComments() [][]string {
+	return l.nested
+}
+
+// isNested checks if a block comment can be nested.
+func (l *Language) isNested(comment [2]string) bool {
+	return containsBlockComment(l.nested, comment[:])
+}
+
+// Strings returns string literals delimiters.
+func (l *Language) Strings() []StringDelimiter {
+	return l.strings
+}
+
+// getShebang returns shebang interpreter.
+func getShebang(line string) (interpreter string, ok bool) {
 	ret := shebangEnvRegex.FindAllStringSubmatch(line, -1)
 	if ret != nil && len(ret[0]) == 3 {
-		shebangLang = ret[0][2]
-		if sl, ok := shebangToExtension[shebangLang]; ok {
-			return sl, ok
-		}
-		return shebangLang, true
+		return ret[0][2], true
 	}
 
 	ret = shebangLangRegex.FindAllStringSubmatch(line, -1)
 	if ret != nil && len(ret[0]) >= 2 {
-		shebangLang = ret[0][1]
-		if sl, ok := shebangToExtension[shebangLang]; ok {
-			return sl, ok
-		}
-		return shebangLang, true
+		return ret[0][1], true
 	}
 
 	return "", false
 }
 
-// getExtensionByShebang returns extension from shebang.
-func getExtensionByShebang(path string) (shebangLang string, ok bool) {
-	f, err := os.Open(path)
-	if err != nil {
-		return shebangLang, false
-	}
-	defer f.Close()
-
-	reader := bufio.NewReader(f)
-	line, err := reader.ReadBytes('\n')
-	if err != nil {
-		return shebangLang, ok
+// getInterpreterByShebang returns interpreter from the shebang of a file content.
+func getInterpreterByShebang(content []byte) (interpreter string, ok bool) {
+	i := bytes.IndexByte(content, '\n')
+	if i < 0 {
+		return interpreter, ok
 	}
-	line = bytes.TrimLeftFunc(line, unicode.IsSpace)
+	line := bytes.TrimLeftFunc(content[:i+1], unicode.IsSpace)
 
 	if len(line) > 2 && line[0] == '#' && line[1] == '!' {
 		return getShebang(string(line))
 	}
-	return shebangLang, ok
+	return interpreter, ok
 }
 
-// getTotalFiles returns the number of files to analyze.
-func getTotalFiles(langs map[string]*Language) (t int) {
-	for _, lang := range langs {
-		t += len(lang.Files)
+// copyStringMap returns a copy of a map.
+func copyStringMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
 	}
-	return t
+	return c
 }
 
 // isLanguageAnalysable checks if a language must be analyze
 // (VCS, match and not-match directory).
 // The function returns true if it can be analyzed.
-func isLanguageAnalysable(path string, vcsInRoot bool, opts *Options) bool {
+// If not, it returns the reason why the file is skipped.
+func isLanguageAnalysable(path string, vcsInRoot bool, opts *Options) (reason string, ok bool) {
 	// Check VCS
 	// ---------
 	if !vcsInRoot && isVCSDir(path) {
-		return false
+		return SkippedVCS, false
 	}
 
 	// Check match and not-match directory options
 	// -------------------------------------------
 	dir := filepath.Dir(path)
 	if opts.NotMatchDir != nil && opts.NotMatchDir.MatchString(dir) {
-		return false
+		return SkippedNotMatchDir, false
 	}
 	if opts.MatchDir != nil && !opts.MatchDir.MatchString(dir) {
-		return false
+		return SkippedMatchDir, false
 	}
-	return true
+	return "", true
 }
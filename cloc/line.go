@@ -0,0 +1,102 @@
+package cloc
+
+import (
+	"bufio"
+	"context"
+	"hash"
+	"io"
+	"sync"
+)
+
+// lineReaderSize is the size of the lineReader buffer.
+// Longer lines are read in chunks: they are never held whole in memory.
+const lineReaderSize = 64 * 1024
+
+// sniffLength is the max length of the beginning of a file used to detect
+// its language (shebang, enry classifiers).
+const sniffLength = 16 * 1024
+
+var readerPool = sync.Pool{
+	New: func() interface{} {
+		return bufio.NewReaderSize(nil, lineReaderSize)
+	},
+}
+
+// getReader returns a buffered reader of r.
+func getReader(r io.Reader) *bufio.Reader {
+	br := readerPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	return br
+}
+
+// putReader puts a buffered reader back in the pool.
+func putReader(br *bufio.Reader) {
+	br.Reset(nil)
+	readerPool.Put(br)
+}
+
+// ctxReader is a reader stopping when its context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read reads from the underlying reader unless the context is done.
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// lineReader reads lines of any length in bounded memory.
+// Lines longer than the buffer are returned in chunks.
+// The content read can be hashed on the fly (duplicates detection).
+type lineReader struct {
+	r    *bufio.Reader
+	hash hash.Hash // Content hash (nil if not needed)
+	more bool      // The last chunk returned is not the end of its line
+}
+
+// newLineReader returns a pointer to a lineReader.
+// If h is not nil, all the content read is written to it.
+func newLineReader(r *bufio.Reader, h hash.Hash) *lineReader {
+	return &lineReader{
+		r:    r,
+		hash: h,
+	}
+}
+
+// next returns the next chunk of a line without end of line characters and
+// true if it is the last chunk of its line.
+// The chunk is only valid until the next call.
+// It returns io.EOF when there is no more line.
+func (lr *lineReader) next() (chunk []byte, last bool, err error) {
+	chunk, err = lr.r.ReadSlice('\n')
+	if lr.hash != nil {
+		lr.hash.Write(chunk)
+	}
+
+	switch {
+	case err == bufio.ErrBufferFull:
+		lr.more = true
+		return chunk, false, nil
+	case err == io.EOF && len(chunk) == 0 && !lr.more:
+		return nil, true, io.EOF
+	case err != nil && err != io.EOF:
+		return nil, true, err
+	}
+	lr.more = false
+
+	// Trim end of line
+	// ----------------
+	n := len(chunk)
+	if n > 0 && chunk[n-1] == '\n' {
+		n--
+	}
+	if n > 0 && chunk[n-1] == '\r' {
+		n--
+	}
+
+	return chunk[:n], true, nil
+}
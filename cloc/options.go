@@ -1,8 +1,8 @@
 package cloc
 
 import (
-	"fmt"
 	"regexp"
+	"runtime"
 
 	"github.com/fabienbellanger/goutils"
 )
@@ -10,54 +10,92 @@ import (
 // Options lists CLOC application options.
 type Options struct {
 	ByFile         bool
+	KeepFiles      bool // Keep files results even if ByFile is false
 	Debug          bool
 	SkipDuplicated bool
+	NoIgnore       bool     // Do not respect .gitignore, .ignore and .gcaignore files
+	Exclude        []string // Excluded paths (gitignore patterns relative to analyzed paths)
 	ExcludeExts    map[string]struct{}
 	IncludeLangs   map[string]struct{}
 	MatchDir       *regexp.Regexp
 	NotMatchDir    *regexp.Regexp
 	Sort           string
+	Jobs           int
+
+	// MinifiedLineLength is the line length from which a file is considered
+	// as minified or generated (0 to disable).
+	MinifiedLineLength int
+
+	// MixedLines is the way lines with code and comments are counted
+	// (MixedAsCode, MixedAsComment or MixedAsBoth).
+	MixedLines string
+
+	// Embedded is the way embedded languages blocks are counted
+	// (EmbeddedRollup or EmbeddedSeparate).
+	Embedded string
 }
 
+// Mixed lines policies
+const (
+	MixedAsCode    = "code"
+	MixedAsComment = "comment"
+	MixedAsBoth    = "both"
+)
+
+// MixedPolicies lists the possible mixed lines policies.
+var MixedPolicies = []string{MixedAsCode, MixedAsComment, MixedAsBoth}
+
 // NewOptions returns application options.
 func NewOptions() *Options {
 	return &Options{
 		ByFile:         false,
+		KeepFiles:      false,
 		Debug:          false,
 		SkipDuplicated: false,
+		NoIgnore:       false,
+		Exclude:        []string{},
 		ExcludeExts:    make(map[string]struct{}),
 		IncludeLangs:   make(map[string]struct{}),
 		Sort:           "code",
+		Jobs:           runtime.NumCPU(),
+
+		MinifiedLineLength: 5000,
+		MixedLines:         MixedAsCode,
+		Embedded:           EmbeddedRollup,
 	}
 }
 
+// SortTypes lists the possible sort values.
+var SortTypes = []string{"code", "size", "lines", "comments", "blanks", "files"}
+
 // CheckSort checks if sort value is correct (code, size, lines, comments, blanks or files).
 func CheckSort(s string) bool {
-	correctSorts := []string{"code", "size", "lines", "comments", "blanks", "files"}
-	return goutils.StringInSlice(s, correctSorts)
+	return goutils.StringInSlice(s, SortTypes)
 }
 
-// checkFileOptions checks if a file respects options.
-func checkFileOptions(path, lang string, opts *Options, filesCache map[string]struct{}) bool {
+// CheckMixed checks if mixed lines policy is correct (code, comment or both).
+func CheckMixed(s string) bool {
+	return goutils.StringInSlice(s, MixedPolicies)
+}
+
+// CheckEmbedded checks if embedded languages policy is correct (rollup or separate).
+func CheckEmbedded(s string) bool {
+	return goutils.StringInSlice(s, EmbeddedPolicies)
+}
+
+// checkFileOptions checks if a file language respects options.
+// If not, it returns the reason why the file is skipped.
+// Duplicates are checked once all files are analyzed (see Processor.reduce).
+func checkFileOptions(lang string, opts *Options) (reason string, ok bool) {
 	if _, ok := opts.ExcludeExts[lang]; ok {
-		return false
+		return SkippedExcludedExt, false
 	}
 
 	if len(opts.IncludeLangs) != 0 {
 		if _, ok := opts.IncludeLangs[lang]; !ok {
-			return false
-		}
-	}
-
-	if !opts.SkipDuplicated {
-		ignore := checkMD5Sum(path, filesCache)
-		if ignore {
-			if opts.Debug {
-				fmt.Printf("[ignore=%v] find same md5\n", path)
-			}
-			return false
+			return SkippedNotIncludedLang, false
 		}
 	}
 
-	return true
+	return "", true
 }
@@ -0,0 +1,385 @@
+package cloc
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lineScanner classifies the lines of a file.
+// Opened block comments and string literal are kept from one line to the next,
+// so comment markers inside string literals and string delimiters inside
+// comments are ignored.
+// Block comments are nested only if the language allows it for their delimiters.
+type lineScanner struct {
+	lang     *Language
+	starts   [utf8.RuneSelf]bool // First bytes of comments and strings delimiters
+	comments [][2]string         // Opened block comments
+	str      *StringDelimiter    // Opened string literal
+	docBlock bool                // Opened block comment is a documentation comment
+
+	// Docstrings
+	docstring bool // Next statement can be a docstring
+	header    bool // Inside a definition header (ex.: "def f(" without ":")
+	brackets  int  // Opened brackets of a definition header
+
+	// overlap is the length of the end of a chunk scanned again with the
+	// next chunk of a long line, so delimiters are not split.
+	overlap int
+	line    lineState
+}
+
+// lineState is the state of the line being scanned.
+type lineState struct {
+	code        bool   // Line contains code (string literals included)
+	comment     bool   // Line contains comments
+	doc         bool   // Line contains documentation comments
+	start       bool   // Nothing is scanned yet
+	continued   bool   // End of line is escaped in a string literal
+	lineComment bool   // The rest of the line is a line comment
+	lastCode    byte   // Last byte of code (out of string literals)
+	tail        string // End of the previous chunk, not scanned yet
+}
+
+// newLineScanner returns a pointer to a lineScanner for a language.
+func newLineScanner(lang *Language) *lineScanner {
+	s := &lineScanner{
+		lang:      lang,
+		comments:  make([][2]string, 0),
+		docstring: len(lang.docs.docstrings) > 0,
+	}
+
+	markers := make([]string, 0)
+	markers = append(markers, lang.lineComments...)
+	for _, ml := range lang.multiLines {
+		markers = append(markers, ml[0])
+	}
+	for _, d := range lang.strings {
+		markers = append(markers, d.Begin)
+	}
+	for _, m := range markers {
+		if len(m) > s.overlap {
+			s.overlap = len(m)
+		}
+		if m != "" {
+			if m[0] >= utf8.RuneSelf {
+				// Non ASCII markers are checked at each position
+				for i := range s.starts {
+					s.starts[i] = true
+				}
+				break
+			}
+			s.starts[m[0]] = true
+		}
+	}
+
+	for _, ml := range lang.multiLines {
+		if len(ml[1]) > s.overlap {
+			s.overlap = len(ml[1])
+		}
+	}
+	for _, d := range lang.strings {
+		if n := len(d.End) + len(d.Escape); n > s.overlap {
+			s.overlap = n
+		}
+	}
+	s.overlap += maxEscapedChar + utf8.UTFMax
+
+	return s
+}
+
+// inComments returns true if a block comment is opened.
+func (s *lineScanner) inComments() bool {
+	return len(s.comments) > 0
+}
+
+// scan scans a trimmed line and returns true if it contains code
+// (string literals included), true if it contains comments and true
+// if it contains documentation comments.
+func (s *lineScanner) scan(line string) (code, comment, doc bool) {
+	s.beginLine(line)
+	s.scanChunk(line, true)
+	return s.endLine()
+}
+
+// beginLine begins the scan of a line. head is the trimmed line, or its
+// first chunk for lines longer than the reader buffer.
+func (s *lineScanner) beginLine(head string) {
+	s.line = lineState{start: true}
+
+	// Definition header
+	if def := s.lang.docs.definition; def != nil && !s.header && s.str == nil && !s.inComments() && def.MatchString(head) {
+		s.header, s.brackets = true, 0
+	}
+}
+
+// scanChunk scans a chunk of the current line.
+// Unless last is true, the end of the chunk which could be the beginning of
+// a delimiter is kept to be scanned with the next chunk.
+func (s *lineScanner) scanChunk(chunk string, last bool) {
+	l := &s.line
+	if l.lineComment {
+		return
+	}
+
+	line := chunk
+	if l.tail != "" {
+		line, l.tail = l.tail+chunk, ""
+	}
+	lenLine := len(line)
+	limit := lenLine
+	if !last {
+		limit -= s.overlap
+	}
+
+	pos := 0
+	for pos < limit {
+		// Inside a string literal
+		// -----------------------
+		if s.str != nil {
+			l.code = true
+			if esc := s.str.Escape; esc != "" && strings.HasPrefix(line[pos:], esc) {
+				pos += len(esc)
+				if pos >= lenLine {
+					l.continued = true
+					break
+				}
+				_, size := utf8.DecodeRuneInString(line[pos:])
+				pos += size
+				continue
+			}
+			if strings.HasPrefix(line[pos:], s.str.End) {
+				pos += len(s.str.End)
+				s.str = nil
+				continue
+			}
+			pos++
+			continue
+		}
+
+		// Inside a block comment
+		// ----------------------
+		if n := len(s.comments); n > 0 {
+			l.comment = true
+			l.doc = l.doc || s.docBlock
+			opened := s.comments[n-1]
+			if strings.HasPrefix(line[pos:], opened[1]) {
+				s.comments = s.comments[:n-1]
+				s.docBlock = s.docBlock && len(s.comments) > 0
+				pos += len(opened[1])
+				continue
+			}
+			// Only comments of the same kind are nested
+			if opened[0] != opened[1] && s.lang.isNested(opened) && strings.HasPrefix(line[pos:], opened[0]) {
+				s.comments = append(s.comments, opened)
+				pos += len(opened[0])
+				continue
+			}
+			pos++
+			continue
+		}
+
+		// Docstring opening the statement
+		// -------------------------------
+		if l.start && pos == 0 && s.docstring {
+			if d, ok := s.matchDocstring(line); ok {
+				l.comment, l.doc = true, true
+				s.docstring, s.docBlock = false, true
+				s.comments = append(s.comments, [2]string{d, d})
+				pos += len(d)
+				continue
+			}
+		}
+
+		// Code
+		// ----
+		c := line[pos]
+		if c < utf8.RuneSelf && !s.starts[c] {
+			if !unicode.IsSpace(rune(c)) {
+				l.code, l.lastCode = true, c
+				s.countBracket(c)
+			}
+			pos++
+			continue
+		}
+
+		// The longest delimiter starting at pos wins
+		// (block comments, then line comments, then strings if equal)
+		// ------------------------------------------------------------
+		begin, end, isBlock := s.matchBlockComment(line, pos)
+		lineComment, isLine := s.matchLineComment(line, pos)
+		str, strLength, isStr := s.matchString(line, pos)
+
+		switch {
+		case isBlock && len(begin) >= len(lineComment) && (!isStr || len(begin) >= len(str.Begin)):
+			l.comment = true
+			s.docBlock = isDocMarker(line, pos, s.lang.docs.blocks)
+			l.doc = l.doc || s.docBlock
+			s.comments = append(s.comments, [2]string{begin, end})
+			pos += len(begin)
+		case isLine && (!isStr || len(lineComment) >= len(str.Begin)):
+			l.comment, l.lineComment = true, true
+			l.doc = l.doc || isDocMarker(line, pos, s.lang.docs.lines)
+			pos = lenLine
+		case isStr && str.Char:
+			l.code = true
+			pos += strLength
+		case isStr:
+			l.code = true
+			s.str = str
+			pos += len(str.Begin)
+		default:
+			r, size := utf8.DecodeRuneInString(line[pos:])
+			if !unicode.IsSpace(r) {
+				l.code, l.lastCode = true, line[pos]
+				s.countBracket(line[pos])
+			}
+			pos += size
+		}
+	}
+
+	l.start = false
+	if pos < lenLine {
+		l.tail = line[pos:]
+	}
+}
+
+// endLine ends the scan of a line and returns true if it contains code
+// (string literals included), true if it contains comments and true
+// if it contains documentation comments.
+func (s *lineScanner) endLine() (code, comment, doc bool) {
+	l := &s.line
+
+	// Single line strings are closed at the end of the line
+	// unless the end of line is escaped.
+	if s.str != nil && !s.str.Multiline && !l.continued {
+		s.str = nil
+	}
+
+	if l.code && len(s.lang.docs.docstrings) > 0 {
+		s.nextDocstring(l.lastCode)
+	}
+
+	return l.code, l.comment, l.doc
+}
+
+// matchDocstring returns the docstring delimiter beginning line.
+func (s *lineScanner) matchDocstring(line string) (string, bool) {
+	for _, d := range s.lang.docs.docstrings {
+		if strings.HasPrefix(line, d) {
+			return d, true
+		}
+	}
+	return "", false
+}
+
+// nextDocstring updates after a line of code whether the next statement
+// can be a docstring: only the first statement of a definition (after its
+// header ending with ":") can be.
+func (s *lineScanner) nextDocstring(lastCode byte) {
+	s.docstring = false
+	if s.header && s.brackets <= 0 {
+		s.header = false
+		s.docstring = lastCode == ':' && s.str == nil && !s.inComments()
+	}
+}
+
+// countBracket counts opened brackets of a definition header.
+func (s *lineScanner) countBracket(c byte) {
+	if !s.header {
+		return
+	}
+	switch c {
+	case '(', '[', '{':
+		s.brackets++
+	case ')', ']', '}':
+		s.brackets--
+	}
+}
+
+// matchBlockComment returns the longest block comment delimiters beginning at pos.
+func (s *lineScanner) matchBlockComment(line string, pos int) (begin, end string, ok bool) {
+	for _, ml := range s.lang.multiLines {
+		if ml[0] != "" && len(ml[0]) > len(begin) && strings.HasPrefix(line[pos:], ml[0]) {
+			begin, end, ok = ml[0], ml[1], true
+		}
+	}
+	return begin, end, ok
+}
+
+// matchLineComment returns the longest line comment delimiter beginning at pos.
+func (s *lineScanner) matchLineComment(line string, pos int) (lineComment string, ok bool) {
+	for _, lc := range s.lang.lineComments {
+		if lc != "" && len(lc) > len(lineComment) && strings.HasPrefix(line[pos:], lc) {
+			lineComment, ok = lc, true
+		}
+	}
+	return lineComment, ok
+}
+
+// matchString returns the longest string delimiter beginning at pos with
+// the length of the literal if it is a character literal.
+// Delimiters beginning with a letter (raw strings prefixes for example)
+// cannot follow an identifier character.
+func (s *lineScanner) matchString(line string, pos int) (*StringDelimiter, int, bool) {
+	var str *StringDelimiter
+	length := 0
+	for i, d := range s.lang.strings {
+		if (str != nil && len(d.Begin) <= len(str.Begin)) || !strings.HasPrefix(line[pos:], d.Begin) {
+			continue
+		}
+		if isIdentifierByte(d.Begin[0]) && pos > 0 && isIdentifierByte(line[pos-1]) {
+			continue
+		}
+		n := len(d.Begin)
+		if d.Char {
+			var ok bool
+			if n, ok = matchChar(line[pos:], &d); !ok {
+				continue
+			}
+		}
+		str, length = &s.lang.strings[i], n
+	}
+	return str, length, str != nil
+}
+
+// maxEscapedChar is the max length of an escaped character (ex.: \u{10FFFF}).
+const maxEscapedChar = 10
+
+// matchChar returns the length of the character literal beginning line.
+func matchChar(line string, d *StringDelimiter) (int, bool) {
+	pos := len(d.Begin)
+	if pos >= len(line) {
+		return 0, false
+	}
+
+	if d.Escape != "" && strings.HasPrefix(line[pos:], d.Escape) {
+		// Escaped character: End is searched after the escaped character
+		pos += len(d.Escape)
+		if pos >= len(line) {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(line[pos:])
+		pos += size
+		if d.End == "" {
+			return pos, true
+		}
+		end := strings.Index(line[pos:], d.End)
+		if end < 0 || end > maxEscapedChar {
+			return 0, false
+		}
+		return pos + end + len(d.End), true
+	}
+
+	_, size := utf8.DecodeRuneInString(line[pos:])
+	pos += size
+	if !strings.HasPrefix(line[pos:], d.End) {
+		return 0, false
+	}
+	return pos + len(d.End), true
+}
+
+// isIdentifierByte checks if a byte can be part of an identifier.
+func isIdentifierByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,130 @@
+package cloc
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// fixture is a source file of testdata with its expected counts.
+type fixture struct {
+	file     string
+	lang     string
+	code     int32
+	comments int32
+	blanks   int32
+}
+
+// testFixtures analyzes fixtures and checks their counts.
+func testFixtures(t *testing.T, dir string, fixtures []fixture) {
+	t.Helper()
+
+	for _, tt := range fixtures {
+		t.Run(tt.file, func(t *testing.T) {
+			content, err := ioutil.ReadFile(filepath.Join("testdata", dir, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f := analyzeSource(t, tt.lang, string(content))
+			if f.Code != tt.code || f.Comments != tt.comments || f.Blanks != tt.blanks {
+				t.Errorf("code: %d, comments: %d, blanks: %d; want code: %d, comments: %d, blanks: %d",
+					f.Code, f.Comments, f.Blanks, tt.code, tt.comments, tt.blanks)
+			}
+		})
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	testFixtures(t, "strings", []fixture{
+		{"urls.go", "Go", 6, 3, 2},
+		{"markers.c", "C", 5, 3, 1},
+		{"raw.cpp", "C++", 4, 1, 0},
+		{"strings.py", "Python", 9, 1, 0},
+		{"template.js", "JavaScript", 5, 1, 0},
+		{"chars.rs", "Rust", 8, 2, 0},
+		{"chars.lisp", "LISP", 3, 3, 0},
+		{"chars.clj", "Clojure", 2, 2, 0},
+		{"chars.rkt", "Racket", 3, 3, 0},
+		{"chars.hs", "Haskell", 3, 2, 0},
+		{"quotes.sh", "Bourne Shell", 4, 1, 0},
+		{"quotes.sql", "SQL", 2, 2, 0},
+		{"long.lua", "Lua", 4, 1, 0},
+	})
+}
+
+func TestNestedComments(t *testing.T) {
+	testFixtures(t, "nested", []fixture{
+		{"flat.c", "C", 2, 3, 0},
+		{"flat.java", "Java", 1, 1, 0},
+		{"flat.go", "Go", 2, 1, 1},
+		{"nested.rs", "Rust", 1, 4, 0},
+		{"nested.hs", "Haskell", 1, 2, 0},
+		{"nested.swift", "Swift", 1, 2, 0},
+		{"mixed.d", "D", 3, 4, 0},
+		{"nested.ml", "OCaml", 1, 2, 0},
+	})
+}
+
+func TestDocComments(t *testing.T) {
+	for _, tt := range []struct {
+		file     string
+		lang     string
+		code     int32
+		comments int32
+		docs     int32
+	}{
+		{"docstrings.py", "Python", 14, 7, 5},
+		{"docstrings.py", "Cython", 14, 7, 5},
+		{"doc.go", "Go", 14, 11, 6},
+		{"doc.rs", "Rust", 3, 9, 5},
+		{"Doc.java", "Java", 4, 7, 4},
+		{"doc.js", "JavaScript", 3, 5, 4},
+	} {
+		t.Run(tt.lang, func(t *testing.T) {
+			content, err := ioutil.ReadFile(filepath.Join("testdata", "docs", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f := analyzeSource(t, tt.lang, string(content))
+			if f.Code != tt.code || f.Comments != tt.comments || f.Docs != tt.docs {
+				t.Errorf("code: %d, comments: %d, docs: %d; want code: %d, comments: %d, docs: %d",
+					f.Code, f.Comments, f.Docs, tt.code, tt.comments, tt.docs)
+			}
+		})
+	}
+}
+
+func TestMixedLines(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "mixed", "mixed.c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mixed.c has 4 mixed lines (one of them after a block comment closing
+	// mid-line), 1 line of code, 3 lines of comments and 1 blank line.
+	tests := []struct {
+		policy   string
+		code     int32
+		comments int32
+		mixed    int32
+	}{
+		{MixedAsCode, 5, 3, 4},
+		{MixedAsComment, 1, 7, 4},
+		{MixedAsBoth, 5, 7, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			opts := NewOptions()
+			opts.MixedLines = tt.policy
+
+			f := analyzeSourceWith(t, "C", string(content), opts)
+			if f.Code != tt.code || f.Comments != tt.comments || f.Mixed != tt.mixed || f.Blanks != 1 {
+				t.Errorf("code: %d, comments: %d, mixed: %d, blanks: %d; want code: %d, comments: %d, mixed: %d, blanks: 1",
+					f.Code, f.Comments, f.Mixed, f.Blanks, tt.code, tt.comments, tt.mixed)
+			}
+		})
+	}
+}
@@ -23,10 +23,10 @@ func LanguagesByCode(i, j *Language) bool {
 
 // LanguagesByFiles sorts languages by files.
 func LanguagesByFiles(i, j *Language) bool {
-	if len(i.Files) == len(j.Files) {
+	if i.Total == j.Total {
 		return i.Name < j.Name
 	}
-	return len(i.Files) > len(j.Files)
+	return i.Total > j.Total
 }
 
 // LanguagesBySize sorts languages by size.
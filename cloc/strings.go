@@ -0,0 +1,125 @@
+package cloc
+
+// String literals delimiters
+var (
+	dquoteString          = StringDelimiter{Begin: `"`, End: `"`, Escape: `\`}
+	squoteString          = StringDelimiter{Begin: `'`, End: `'`, Escape: `\`}
+	rawDquoteString       = StringDelimiter{Begin: `"`, End: `"`}
+	rawSquoteString       = StringDelimiter{Begin: `'`, End: `'`}
+	multiDquoteString     = StringDelimiter{Begin: `"`, End: `"`, Escape: `\`, Multiline: true}
+	multiSquoteString     = StringDelimiter{Begin: `'`, End: `'`, Escape: `\`, Multiline: true}
+	tripleDquoteString    = StringDelimiter{Begin: `"""`, End: `"""`, Escape: `\`, Multiline: true}
+	tripleSquoteString    = StringDelimiter{Begin: `'''`, End: `'''`, Escape: `\`, Multiline: true}
+	rawTripleDquoteString = StringDelimiter{Begin: `"""`, End: `"""`, Multiline: true}
+	rawTripleSquoteString = StringDelimiter{Begin: `'''`, End: `'''`, Multiline: true}
+	backquoteString       = StringDelimiter{Begin: "`", End: "`", Escape: `\`, Multiline: true}
+	rawBackquoteString    = StringDelimiter{Begin: "`", End: "`", Multiline: true}
+	squoteChar            = StringDelimiter{Begin: `'`, End: `'`, Escape: `\`, Char: true}
+
+	// cStrings are strings and characters of C-like languages.
+	cStrings = []StringDelimiter{dquoteString, squoteString}
+	// cppStrings adds C++ raw strings without custom delimiter.
+	cppStrings = []StringDelimiter{dquoteString, squoteString, {Begin: `R"(`, End: `)"`, Multiline: true}}
+	// jsStrings adds template literals.
+	jsStrings = []StringDelimiter{dquoteString, squoteString, backquoteString}
+	// shellStrings do not span lines to limit the impact of unbalanced quotes.
+	shellStrings = []StringDelimiter{dquoteString, rawSquoteString}
+	// lispStrings are strings and characters (#\x) of Lisp dialects
+	// (quote is not a string delimiter).
+	lispStrings = []StringDelimiter{multiDquoteString, {Begin: `#\`, Char: true}}
+	// mlStrings are strings and characters of ML and Haskell like languages
+	// (quote is also used in identifiers and type variables).
+	mlStrings = []StringDelimiter{dquoteString, squoteChar}
+)
+
+// languageStrings lists string literals delimiters of built-in languages.
+// Languages which are not listed have no string literals: comment markers
+// are searched everywhere in their lines.
+var languageStrings = map[string][]StringDelimiter{
+	"ActionScript":        cStrings,
+	"Arduino Sketch":      cStrings,
+	"Assembly":            {dquoteString},
+	"Awk":                 {dquoteString},
+	"BASH":                shellStrings,
+	"Bourne Shell":        shellStrings,
+	"C":                   cStrings,
+	"C Header":            cStrings,
+	"C Shell":             shellStrings,
+	"C#":                  {{Begin: `@"`, End: `"`, Multiline: true}, dquoteString, squoteString},
+	"C++":                 cppStrings,
+	"C++ Header":          cppStrings,
+	"Chapel":              cStrings,
+	"Clojure":             {multiDquoteString, {Begin: `\`, Char: true}},
+	"CoffeeScript":        {tripleDquoteString, tripleSquoteString, dquoteString, squoteString},
+	"ColdFusion CFScript": cStrings,
+	"Crystal":             cStrings,
+	"CSS":                 cStrings,
+	"CUDA":                cStrings,
+	"Cython":              {tripleDquoteString, tripleSquoteString, dquoteString, squoteString},
+	"D":                   {dquoteString, squoteString, rawBackquoteString, {Begin: `r"`, End: `"`, Multiline: true}},
+	"Dart":                {tripleDquoteString, tripleSquoteString, dquoteString, squoteString},
+	"Dhall":               mlStrings,
+	"Elixir":              {tripleDquoteString, dquoteString, squoteString},
+	"Elm":                 mlStrings,
+	"Erlang":              {dquoteString},
+	"F#":                  mlStrings,
+	"Fish":                shellStrings,
+	"Frege":               mlStrings,
+	"GLSL":                {dquoteString},
+	"Go":                  {dquoteString, squoteString, rawBackquoteString},
+	"Groovy":              {tripleDquoteString, tripleSquoteString, dquoteString, squoteString},
+	"Haskell":             mlStrings,
+	"Haxe":                cStrings,
+	"HCL":                 {dquoteString},
+	"HLSL":                {dquoteString},
+	"Idris":               mlStrings,
+	"Io":                  {tripleDquoteString, dquoteString},
+	"JAI":                 cStrings,
+	"Janet":               {multiDquoteString},
+	"Java":                {tripleDquoteString, dquoteString, squoteString},
+	"JavaScript":          jsStrings,
+	"JSON":                {dquoteString},
+	"JSX":                 jsStrings,
+	"Julia":               {tripleDquoteString, dquoteString},
+	"Kotlin":              {rawTripleDquoteString, dquoteString, squoteString},
+	"LESS":                cStrings,
+	"LISP":                lispStrings,
+	"LiveScript":          {tripleDquoteString, tripleSquoteString, dquoteString, squoteString},
+	"Lua":                 {dquoteString, squoteString, {Begin: "[[", End: "]]", Multiline: true}},
+	"MATLAB":              {dquoteString},
+	"Mercury":             {dquoteString},
+	"Nim":                 {rawTripleDquoteString, dquoteString, squoteString},
+	"Nix":                 {multiDquoteString, {Begin: "''", End: "''", Multiline: true}},
+	"Objective-C":         cStrings,
+	"Objective-C++":       cppStrings,
+	"OCaml":               mlStrings,
+	"Perl":                {dquoteString, squoteString},
+	"PHP":                 {multiDquoteString, multiSquoteString},
+	"Pony":                {rawTripleDquoteString, dquoteString, squoteString},
+	"PowerShell":          {{Begin: `"`, End: `"`, Escape: "`"}, rawSquoteString},
+	"Protocol Buffers":    cStrings,
+	"Python":              {tripleDquoteString, tripleSquoteString, dquoteString, squoteString},
+	"QML":                 jsStrings,
+	"R":                   {dquoteString, squoteString},
+	"Racket":              lispStrings,
+	"Ruby":                {dquoteString, squoteString},
+	"Rust":                {{Begin: `r#"`, End: `"#`, Multiline: true}, {Begin: `r"`, End: `"`, Multiline: true}, multiDquoteString, squoteChar},
+	"Sass":                cStrings,
+	"Scala":               {rawTripleDquoteString, dquoteString, squoteString},
+	"Scheme":              lispStrings,
+	"Solidity":            cStrings,
+	"SQL":                 {rawSquoteString, rawDquoteString},
+	"Stan":                {dquoteString},
+	"Standard ML":         mlStrings,
+	"Swift":               {tripleDquoteString, dquoteString},
+	"Tcl/Tk":              {dquoteString},
+	"Terra":               {dquoteString, squoteString, {Begin: "[[", End: "]]", Multiline: true}},
+	"TOML":                {tripleDquoteString, rawTripleSquoteString, dquoteString, rawSquoteString},
+	"TypeScript":          jsStrings,
+	"Vala":                {tripleDquoteString, dquoteString, squoteString},
+	"Verilog":             {dquoteString},
+	"YAML":                {dquoteString, rawSquoteString},
+	"Zephir":              cStrings,
+	"Zig":                 cStrings,
+	"Zsh":                 shellStrings,
+}
@@ -0,0 +1,11 @@
+/**
+ * Documented class.
+ */
+public class Doc {
+    /* Ordinary block. */
+    // Ordinary line.
+    /** Documented field. */
+    int x;
+    /**/
+    int y;
+}
@@ -0,0 +1,30 @@
+// Package p is documented.
+package p
+
+// Exported is documented.
+type Exported struct {
+	// Field is documented.
+	Field int
+
+	// private field is not documented.
+	private int
+}
+
+// Grouped declarations.
+const (
+	// A is documented.
+	A = 1
+	// b is not exported.
+	b = 2
+)
+
+// helper is not exported.
+func helper() int {
+	// X is an assignment, not a declaration.
+	X := 1
+	return X
+}
+
+// F is documented
+// on two lines.
+func F() {}
@@ -0,0 +1,8 @@
+/**
+ * Documented function.
+ * @param {number} a
+ */
+function f(a) {
+  // Ordinary comment.
+  return a; /** trailing doc */
+}
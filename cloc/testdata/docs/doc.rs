@@ -0,0 +1,15 @@
+//! Crate documentation.
+//! Second line.
+
+/// Documented function.
+fn f() {}
+
+// Ordinary comment.
+//// Four slashes is not a doc.
+/** Block doc. */
+struct S;
+
+/*! Inner block doc. */
+/* Ordinary block. */
+/**/
+fn g() {}
@@ -0,0 +1,29 @@
+#!/usr/bin/env python
+# -*- coding: utf-8 -*-
+"""Module docstring.
+
+Two paragraphs.
+"""
+import os
+
+QUERY = """
+SELECT 1
+"""
+
+
+@decorator
+def f(a,
+      b=(1, 2)):
+    '''Function docstring.'''
+    x = '''not a docstring'''
+    return x
+
+
+class A(object):  # comment
+    # comment before the docstring
+    """Class docstring."""
+
+    async def g(self):
+        pass
+
+    def h(self): return """not a docstring"""
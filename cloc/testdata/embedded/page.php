@@ -0,0 +1,18 @@
+<html>
+<head>
+<style>
+/* title */
+h1 { color: blue; }
+</style>
+</head>
+<body>
+<?php
+// greeting
+echo "hello";
+?>
+<script>
+// click
+document.title = "page";
+</script>
+</body>
+</html>
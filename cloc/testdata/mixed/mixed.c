@@ -0,0 +1,9 @@
+/* header */
+int a = 1; // trailing
+int b = 2; /* block */
+/* starts
+   closes */ int c = 3;
+int d = 4;
+
+// only comment
+int e /* inline */ = 5;
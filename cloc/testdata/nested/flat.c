@@ -0,0 +1,5 @@
+/* a /* b */
+int a;
+/* c
+/* d */
+int b;
@@ -0,0 +1,4 @@
+package p
+
+/* a /* b */
+var x = 1
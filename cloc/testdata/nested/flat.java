@@ -0,0 +1,2 @@
+/* outer /* inner */
+class A {}
@@ -0,0 +1,3 @@
+(* outer (* inner *)
+   still a comment *)
+let x = 1
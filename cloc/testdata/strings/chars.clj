@@ -0,0 +1,4 @@
+(def q \")
+#_(discarded form)
+(def s "#_ not a comment")
+#_(discarded form)
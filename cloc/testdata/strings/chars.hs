@@ -0,0 +1,5 @@
+quote :: Char
+quote = '"'
+-- comment
+f' x = x
+-- comment
@@ -0,0 +1,6 @@
+(defun quote-char ()
+  #\")
+;; comment
+;; comment
+(defun semicolon () #\;)
+;; comment
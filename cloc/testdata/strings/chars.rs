@@ -0,0 +1,10 @@
+fn first<'a>(s: &'a str) -> &'a str {
+    let c = '"';
+    let e = '\'';
+    let b = b'"';
+    let u = '\u{1F600}';
+    let r = r#"// not a comment "quoted""#;
+    // comment
+    // comment
+    s
+}
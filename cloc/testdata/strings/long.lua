@@ -0,0 +1,5 @@
+local s = [[
+-- not a comment
+]]
+-- comment
+print(s)
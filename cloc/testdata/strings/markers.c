@@ -0,0 +1,9 @@
+#include <stdio.h>
+
+char q = '"';
+char *s = "/* not a comment";
+char *t = "escaped \" // still a string";
+// line comment
+/* block
+   comment */
+int main(void) { return 0; }
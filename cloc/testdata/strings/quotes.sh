@@ -0,0 +1,5 @@
+#!/bin/sh
+echo "# not a comment"
+echo '# not a comment either'
+# comment
+echo done
@@ -0,0 +1,4 @@
+SELECT '-- not a comment' FROM t;
+-- comment
+SELECT "/* not a comment" FROM t;
+/* comment */
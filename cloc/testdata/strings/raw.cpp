@@ -0,0 +1,5 @@
+const char *r = R"(
+/* not a comment
+)";
+// line comment
+int x = 1;
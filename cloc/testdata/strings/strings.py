@@ -0,0 +1,10 @@
+url = "http://example.com/#anchor"
+s = '# not a comment'
+t = '''
+# inside a string
+'''
+# comment
+print(url)
+q = """
+# not a comment
+"""
@@ -0,0 +1,6 @@
+const url = `http://example.com
+// inside a template literal
+`;
+const re = "/* not a comment";
+// comment
+console.log(url, re);
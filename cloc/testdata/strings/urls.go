@@ -0,0 +1,11 @@
+package main
+
+// url is not a comment marker inside strings.
+var url = "http://example.com/*"
+var raw = `/* not a comment
+// still a string */`
+var quote = '"'
+
+/* block */
+// line
+func main() {}
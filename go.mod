@@ -6,6 +6,7 @@ require (
 	github.com/fabienbellanger/goutils v1.0.10
 	github.com/logrusorgru/aurora v0.0.0-20200102142835-e9ef32dff381
 	github.com/spf13/cobra v0.0.5
-	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/spf13/pflag v1.0.5
 	github.com/src-d/enry/v2 v2.1.0
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -2,7 +2,7 @@ package output
 
 import (
 	"fmt"
-	"sort"
+	"io"
 	"strings"
 
 	"github.com/fabienbellanger/goCodeAnalyser/cloc"
@@ -19,8 +19,29 @@ func NewConsole() *Console {
 	return &Console{}
 }
 
-// Write displays result in the console.
-func (c *Console) Write(result *cloc.Result, opts *cloc.Options) error {
+// errWriter is a writer keeping the first write error.
+// Once an error occurred, nothing more is written.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+// Write writes p to the underlying writer unless an error already occurred.
+func (ew *errWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+
+	n, err := ew.w.Write(p)
+	ew.err = err
+	return n, err
+}
+
+// Write displays result as a console table.
+// It returns the first error occurred while writing to w.
+func (c *Console) Write(out io.Writer, result *cloc.Result, opts *cloc.Options) error {
+	w := &errWriter{w: out}
+
 	// Max length for title
 	// --------------------
 	maxTitle := maxLanguagesLength
@@ -30,11 +51,13 @@ func (c *Console) Write(result *cloc.Result, opts *cloc.Options) error {
 
 	// Display results
 	// ---------------
-	header(opts.ByFile, maxTitle)
-	body(opts.ByFile, opts.Sort, maxTitle, result)
-	footer(opts.ByFile, maxTitle, result.Total)
+	header(w, opts.ByFile, maxTitle)
+	body(w, opts.ByFile, opts.Sort, maxTitle, result)
+	footer(w, opts.ByFile, maxTitle, result.Total)
+	minifiedSummary(w, result.Minified)
+	errorsSummary(w, result.Errors)
 
-	return nil
+	return w.err
 }
 
 // maxFilesLength returns the max length of files.
@@ -50,50 +73,56 @@ func maxFilesLength(files map[string]*cloc.File) int {
 }
 
 // header displays array header.
-func header(byFile bool, maxLength int) {
-	// 2*2 + 6*(3 + 9) + (maxLength + 4)
+func header(w io.Writer, byFile bool, maxLength int) {
+	// 2*2 + 8*(3 + 9) + (maxLength + 4)
 	title := "Language"
 	if byFile {
 		title = "File"
 	}
-	fmt.Printf("\n%v\n", strings.Repeat("─", 80+maxLength))
-	fmt.Printf("│ %-[1]*[2]v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │\n",
-		maxLength+4, title, "Files", "Size", "Lines", "Blanks", "Comments", "Code")
-	fmt.Printf("%v\n", strings.Repeat("─", 80+maxLength))
+	fmt.Fprintf(w, "\n%v\n", strings.Repeat("─", 104+maxLength))
+	fmt.Fprintf(w, "│ %-[1]*[2]v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │\n",
+		maxLength+4, title, "Files", "Size", "Lines", "Blanks", "Comments", "Docs", "Code", "Mixed")
+	fmt.Fprintf(w, "%v\n", strings.Repeat("─", 104+maxLength))
 }
 
 // footer displays array footer.
-func footer(byFile bool, maxLength int, t *cloc.Language) {
-	fmt.Printf("%v\n", strings.Repeat("─", 80+maxLength))
-	fmt.Printf("│ %-[1]*[2]v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │\n",
-		maxLength+4, "Total", t.Total, goutils.HumanSizeWithPrecision(float64(t.Size), 0), t.Lines, t.Blanks, t.Comments, t.Code)
-	fmt.Printf("%v\n", strings.Repeat("─", 80+maxLength))
+func footer(w io.Writer, byFile bool, maxLength int, t *cloc.Language) {
+	fmt.Fprintf(w, "%v\n", strings.Repeat("─", 104+maxLength))
+	fmt.Fprintf(w, "│ %-[1]*[2]v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │\n",
+		maxLength+4, "Total", t.Total, goutils.HumanSizeWithPrecision(float64(t.Size), 0), t.Lines, t.Blanks, t.Comments, t.Docs, t.Code, t.Mixed)
+	fmt.Fprintf(w, "%v\n", strings.Repeat("─", 104+maxLength))
+}
+
+// minifiedSummary displays minified or generated files.
+func minifiedSummary(w io.Writer, minified []string) {
+	if len(minified) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%d minified or generated file(s):\n", len(minified))
+	for _, path := range minified {
+		fmt.Fprintf(w, "  %s\n", path)
+	}
+}
+
+// errorsSummary displays files which could not be analyzed.
+func errorsSummary(w io.Writer, errors []*cloc.FileError) {
+	if len(errors) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%d error(s) during analysis:\n", len(errors))
+	for _, e := range errors {
+		fmt.Fprintf(w, "  [%s] %s: %s\n", e.Phase, e.Path, e.Message)
+	}
 }
 
 // body displays languages or files information.
-func body(byFile bool, sortType string, maxLength int, r *cloc.Result) {
+func body(w io.Writer, byFile bool, sortType string, maxLength int, r *cloc.Result) {
 	if byFile {
-		// Sort
-		// ----
-		filesSlice := make([]*cloc.File, 0, len(r.Files))
-		for k := range r.Files {
-			filesSlice = append(filesSlice, r.Files[k])
-		}
-		switch sortType {
-		case "size":
-			sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileBySize})
-		case "lines":
-			sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileByLines})
-		case "comments":
-			sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileByComments})
-		case "blanks":
-			sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileByBlanks})
-		default:
-			sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileByCode})
-		}
-
+		filesSlice := sortedFiles(r.Files, sortType)
 		for k := range filesSlice {
-			fmt.Printf("│ %-[1]*[2]v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │\n",
+			fmt.Fprintf(w, "│ %-[1]*[2]v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │\n",
 				maxLength+4,
 				filesSlice[k].Name,
 				"",
@@ -101,32 +130,14 @@ func body(byFile bool, sortType string, maxLength int, r *cloc.Result) {
 				filesSlice[k].Lines,
 				filesSlice[k].Blanks,
 				filesSlice[k].Comments,
-				filesSlice[k].Code)
+				filesSlice[k].Docs,
+				filesSlice[k].Code,
+				filesSlice[k].Mixed)
 		}
 	} else {
-		// Sort by Code
-		// ------------
-		languagesSlice := make([]*cloc.Language, 0, len(r.Languages))
-		for k := range r.Languages {
-			languagesSlice = append(languagesSlice, r.Languages[k])
-		}
-		switch sortType {
-		case "files":
-			sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByFiles})
-		case "size":
-			sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesBySize})
-		case "lines":
-			sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByLines})
-		case "comments":
-			sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByComments})
-		case "blanks":
-			sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByBlanks})
-		default:
-			sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByCode})
-		}
-
+		languagesSlice := sortedLanguages(r.Languages, sortType)
 		for k := range languagesSlice {
-			fmt.Printf("│ %-[1]*[2]v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │\n",
+			fmt.Fprintf(w, "│ %-[1]*[2]v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │ %9v │\n",
 				maxLength+4,
 				languagesSlice[k].Name,
 				languagesSlice[k].Total,
@@ -134,7 +145,9 @@ func body(byFile bool, sortType string, maxLength int, r *cloc.Result) {
 				languagesSlice[k].Lines,
 				languagesSlice[k].Blanks,
 				languagesSlice[k].Comments,
-				languagesSlice[k].Code)
+				languagesSlice[k].Docs,
+				languagesSlice[k].Code,
+				languagesSlice[k].Mixed)
 		}
 	}
 }
@@ -0,0 +1,99 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+)
+
+// CSV type.
+type CSV struct {
+	comma     rune
+	withTotal bool
+}
+
+// NewCSV return a pointer to a CSV (comma separated).
+func NewCSV(withTotal bool) *CSV {
+	return &CSV{
+		comma:     ',',
+		withTotal: withTotal,
+	}
+}
+
+// NewTSV return a pointer to a CSV using tabs as separator.
+func NewTSV(withTotal bool) *CSV {
+	return &CSV{
+		comma:     '\t',
+		withTotal: withTotal,
+	}
+}
+
+// Write displays result in CSV format.
+// Files errors are written after the total as rows beginning with "Error",
+// followed by the path, the phase and the message.
+func (c *CSV) Write(out io.Writer, result *cloc.Result, opts *cloc.Options) error {
+	w := csv.NewWriter(out)
+	w.Comma = c.comma
+
+	t := result.Total
+	var header []string
+	if opts.ByFile {
+		header = []string{"File", "Language", "Size", "Lines", "Blanks", "Comments", "Docs", "Code", "Mixed"}
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		for _, f := range sortedFiles(result.Files, opts.Sort) {
+			if err := w.Write([]string{f.Name, f.Language, formatInt(f.Size),
+				formatInt32(f.Lines), formatInt32(f.Blanks), formatInt32(f.Comments), formatInt32(f.Docs), formatInt32(f.Code), formatInt32(f.Mixed)}); err != nil {
+				return err
+			}
+		}
+		if c.withTotal {
+			if err := w.Write([]string{"Total", "", formatInt(t.Size),
+				formatInt32(t.Lines), formatInt32(t.Blanks), formatInt32(t.Comments), formatInt32(t.Docs), formatInt32(t.Code), formatInt32(t.Mixed)}); err != nil {
+				return err
+			}
+		}
+	} else {
+		header = []string{"Language", "Files", "Size", "Lines", "Blanks", "Comments", "Docs", "Code", "Mixed"}
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		for _, l := range sortedLanguages(result.Languages, opts.Sort) {
+			if err := w.Write([]string{l.Name, formatInt32(l.Total), formatInt(l.Size),
+				formatInt32(l.Lines), formatInt32(l.Blanks), formatInt32(l.Comments), formatInt32(l.Docs), formatInt32(l.Code), formatInt32(l.Mixed)}); err != nil {
+				return err
+			}
+		}
+		if c.withTotal {
+			if err := w.Write([]string{"Total", formatInt32(t.Total), formatInt(t.Size),
+				formatInt32(t.Lines), formatInt32(t.Blanks), formatInt32(t.Comments), formatInt32(t.Docs), formatInt32(t.Code), formatInt32(t.Mixed)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Errors rows have as many fields as other rows for CSV readers
+	for _, e := range result.Errors {
+		row := make([]string, len(header))
+		copy(row, []string{"Error", e.Path, e.Phase, e.Message})
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// formatInt returns the string representation of an int64.
+func formatInt(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+// formatInt32 returns the string representation of an int32.
+func formatInt32(i int32) string {
+	return strconv.FormatInt(int64(i), 10)
+}
@@ -0,0 +1,310 @@
+package output
+
+import (
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+	"github.com/fabienbellanger/goutils"
+)
+
+// HTML type.
+type HTML struct{}
+
+// htmlData is the data passed to the HTML template.
+type htmlData struct {
+	Languages []*cloc.Language
+	Files     []*cloc.File
+	Total     *cloc.Language
+	Errors    []*cloc.FileError
+	Chart     []htmlChartItem
+	Tree      *htmlTreeNode
+}
+
+// htmlChartItem is a language share in the chart.
+type htmlChartItem struct {
+	Name string `json:"name"`
+	Code int32  `json:"code"`
+}
+
+// htmlTreeNode is a directory or a file in the treemap.
+type htmlTreeNode struct {
+	Name     string          `json:"name"`
+	Path     string          `json:"path"`
+	Language string          `json:"language,omitempty"`
+	Code     int32           `json:"code"`
+	Children []*htmlTreeNode `json:"children,omitempty"`
+}
+
+var htmlFuncs = template.FuncMap{
+	"humanSize": func(s int64) string {
+		return goutils.HumanSizeWithPrecision(float64(s), 0)
+	},
+}
+
+// NewHTML return a pointer to a HTML.
+func NewHTML() *HTML {
+	return &HTML{}
+}
+
+// Write displays result as a standalone HTML page.
+func (h *HTML) Write(w io.Writer, result *cloc.Result, opts *cloc.Options) error {
+	t, err := template.New("html").Funcs(htmlFuncs).Parse(htmlTemplate)
+	if err != nil {
+		return err
+	}
+
+	data := htmlData{
+		Languages: sortedLanguages(result.Languages, opts.Sort),
+		Files:     sortedFiles(result.Files, opts.Sort),
+		Total:     result.Total,
+		Errors:    result.Errors,
+	}
+	for _, l := range data.Languages {
+		data.Chart = append(data.Chart, htmlChartItem{Name: l.Name, Code: l.Code})
+	}
+	data.Tree = newHTMLTree(data.Files)
+
+	return t.Execute(w, data)
+}
+
+// newHTMLTree builds the directory tree used by the treemap.
+func newHTMLTree(files []*cloc.File) *htmlTreeNode {
+	root := &htmlTreeNode{Name: ".", Path: "."}
+	for _, f := range files {
+		parts := strings.Split(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(f.Name)), "/"), "/")
+		node := root
+		for i, part := range parts {
+			node.Code += f.Code
+			if i == len(parts)-1 {
+				node.Children = append(node.Children, &htmlTreeNode{
+					Name:     part,
+					Path:     f.Name,
+					Language: f.Language,
+					Code:     f.Code,
+				})
+				break
+			}
+
+			var child *htmlTreeNode
+			for _, c := range node.Children {
+				if c.Name == part && c.Children != nil {
+					child = c
+					break
+				}
+			}
+			if child == nil {
+				child = &htmlTreeNode{
+					Name:     part,
+					Path:     strings.Join(parts[:i+1], "/"),
+					Children: []*htmlTreeNode{},
+				}
+				node.Children = append(node.Children, child)
+			}
+			node = child
+		}
+	}
+	sortHTMLTree(root)
+
+	return root
+}
+
+// sortHTMLTree sorts nodes by code, biggest first.
+func sortHTMLTree(node *htmlTreeNode) {
+	sort.SliceStable(node.Children, func(i, j int) bool {
+		return node.Children[i].Code > node.Children[j].Code
+	})
+	for _, c := range node.Children {
+		sortHTMLTree(c)
+	}
+}
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Go Code Analyser report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #e1e4e8; padding-bottom: .3em; }
+table { border-collapse: collapse; width: 100%; font-size: .9em; }
+th, td { padding: 4px 8px; border: 1px solid #e1e4e8; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; text-align: left; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+td.num, th.num { text-align: right; }
+tfoot td { font-weight: bold; background: #f6f8fa; }
+#chart { display: flex; align-items: center; gap: 2em; }
+#legend div { margin: 2px 0; }
+#legend span { display: inline-block; width: 12px; height: 12px; margin-right: 6px; vertical-align: middle; }
+#treemap { position: relative; width: 100%; height: 500px; border: 1px solid #e1e4e8; }
+#treemap div { position: absolute; box-sizing: border-box; border: 1px solid #fff; overflow: hidden; font-size: 11px; color: #fff; padding: 2px; }
+</style>
+</head>
+<body>
+<h1>Go Code Analyser report</h1>
+
+<h2>Languages</h2>
+<table class="sortable">
+<thead>
+<tr><th>Language</th><th class="num">Files</th><th class="num">Size</th><th class="num">Lines</th><th class="num">Blanks</th><th class="num">Comments</th><th class="num">Docs</th><th class="num">Code</th><th class="num">Mixed</th></tr>
+</thead>
+<tbody>
+{{- range .Languages}}
+<tr><td>{{.Name}}</td><td class="num">{{.Total}}</td><td class="num" data-value="{{.Size}}">{{humanSize .Size}}</td><td class="num">{{.Lines}}</td><td class="num">{{.Blanks}}</td><td class="num">{{.Comments}}</td><td class="num">{{.Docs}}</td><td class="num">{{.Code}}</td><td class="num">{{.Mixed}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+{{- with .Total}}
+<tr><td>Total</td><td class="num">{{.Total}}</td><td class="num">{{humanSize .Size}}</td><td class="num">{{.Lines}}</td><td class="num">{{.Blanks}}</td><td class="num">{{.Comments}}</td><td class="num">{{.Docs}}</td><td class="num">{{.Code}}</td><td class="num">{{.Mixed}}</td></tr>
+{{- end}}
+</tfoot>
+</table>
+
+<h2>Language share (code)</h2>
+<div id="chart"><svg id="pie" width="300" height="300" viewBox="-1 -1 2 2"></svg><div id="legend"></div></div>
+
+<h2>Directories</h2>
+<div id="treemap"></div>
+
+<h2>Files</h2>
+<table class="sortable">
+<thead>
+<tr><th>File</th><th>Language</th><th class="num">Size</th><th class="num">Lines</th><th class="num">Blanks</th><th class="num">Comments</th><th class="num">Docs</th><th class="num">Code</th><th class="num">Mixed</th></tr>
+</thead>
+<tbody>
+{{- range .Files}}
+<tr><td>{{.Name}}</td><td>{{.Language}}</td><td class="num" data-value="{{.Size}}">{{humanSize .Size}}</td><td class="num">{{.Lines}}</td><td class="num">{{.Blanks}}</td><td class="num">{{.Comments}}</td><td class="num">{{.Docs}}</td><td class="num">{{.Code}}</td><td class="num">{{.Mixed}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+{{- if .Errors}}
+
+<h2>Errors</h2>
+<table class="sortable">
+<thead>
+<tr><th>Path</th><th>Phase</th><th>Error</th></tr>
+</thead>
+<tbody>
+{{- range .Errors}}
+<tr><td>{{.Path}}</td><td>{{.Phase}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+<script>
+var chart = {{.Chart}};
+var tree = {{.Tree}};
+
+function color(i) {
+	return "hsl(" + ((i * 137) % 360) + ", 55%, 50%)";
+}
+
+// Sortable tables
+(function () {
+	document.querySelectorAll("table.sortable").forEach(function (table) {
+		table.querySelectorAll("thead th").forEach(function (th, col) {
+			th.addEventListener("click", function () {
+				var asc = !th.classList.contains("asc");
+				table.querySelectorAll("thead th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+				th.classList.add(asc ? "asc" : "desc");
+				var tbody = table.tBodies[0];
+				var rows = Array.prototype.slice.call(tbody.rows);
+				var numeric = th.classList.contains("num");
+				rows.sort(function (a, b) {
+					var x = a.cells[col].dataset.value || a.cells[col].textContent;
+					var y = b.cells[col].dataset.value || b.cells[col].textContent;
+					var r = numeric ? Number(x) - Number(y) : x.localeCompare(y);
+					return asc ? r : -r;
+				});
+				rows.forEach(function (row) { tbody.appendChild(row); });
+			});
+		});
+	});
+})();
+
+// Pie chart
+(function () {
+	var svg = document.getElementById("pie");
+	var legend = document.getElementById("legend");
+	var total = chart.reduce(function (s, l) { return s + l.code; }, 0);
+	if (total === 0) {
+		return;
+	}
+	var angle = -Math.PI / 2;
+	chart.forEach(function (l, i) {
+		var share = l.code / total;
+		var el;
+		if (share >= 1) {
+			el = document.createElementNS("http://www.w3.org/2000/svg", "circle");
+			el.setAttribute("r", "1");
+		} else {
+			var next = angle + share * 2 * Math.PI;
+			var large = share > 0.5 ? 1 : 0;
+			el = document.createElementNS("http://www.w3.org/2000/svg", "path");
+			el.setAttribute("d", "M0,0 L" + Math.cos(angle) + "," + Math.sin(angle) +
+				" A1,1 0 " + large + ",1 " + Math.cos(next) + "," + Math.sin(next) + " Z");
+			angle = next;
+		}
+		el.setAttribute("fill", color(i));
+		var title = document.createElementNS("http://www.w3.org/2000/svg", "title");
+		title.textContent = l.name + ": " + l.code + " (" + (share * 100).toFixed(1) + "%)";
+		el.appendChild(title);
+		svg.appendChild(el);
+
+		var item = document.createElement("div");
+		var box = document.createElement("span");
+		box.style.background = color(i);
+		item.appendChild(box);
+		item.appendChild(document.createTextNode(title.textContent));
+		legend.appendChild(item);
+	});
+})();
+
+// Directory treemap (slice and dice)
+(function () {
+	var container = document.getElementById("treemap");
+	var languages = {};
+	chart.forEach(function (l, i) { languages[l.name] = color(i); });
+
+	function layout(node, x, y, w, h, vertical) {
+		if (!node.children || node.children.length === 0) {
+			var el = document.createElement("div");
+			el.style.left = x + "%";
+			el.style.top = y + "%";
+			el.style.width = w + "%";
+			el.style.height = h + "%";
+			el.style.background = languages[node.language] || "#999";
+			el.title = node.path + " (" + node.language + "): " + node.code;
+			el.textContent = node.name;
+			container.appendChild(el);
+			return;
+		}
+		var offset = 0;
+		node.children.forEach(function (child) {
+			if (child.code <= 0 || node.code <= 0) {
+				return;
+			}
+			var share = child.code / node.code;
+			if (vertical) {
+				layout(child, x, y + offset, w, h * share, !vertical);
+				offset += h * share;
+			} else {
+				layout(child, x + offset, y, w * share, h, !vertical);
+				offset += w * share;
+			}
+		});
+	}
+
+	layout(tree, 0, 0, 100, 100, false);
+})();
+</script>
+</body>
+</html>
+`
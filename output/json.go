@@ -0,0 +1,47 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+)
+
+// JSON type.
+type JSON struct{}
+
+// jsonResult is the JSON representation of a cloc.Result.
+type jsonResult struct {
+	Languages []*cloc.Language  `json:"languages"`
+	Files     []*cloc.File      `json:"files,omitempty"`
+	Total     *cloc.Language    `json:"total"`
+	Errors    []*cloc.FileError `json:"errors,omitempty"`
+	Minified  []string          `json:"minified,omitempty"`
+}
+
+// NewJSON return a pointer to a JSON.
+func NewJSON() *JSON {
+	return &JSON{}
+}
+
+// Write displays result in JSON format.
+func (j *JSON) Write(w io.Writer, result *cloc.Result, opts *cloc.Options) error {
+	r := jsonResult{
+		Languages: sortedLanguages(result.Languages, opts.Sort),
+		Total:     result.Total,
+		Errors:    result.Errors,
+		Minified:  result.Minified,
+	}
+	if opts.ByFile {
+		r.Files = sortedFiles(result.Files, opts.Sort)
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+
+	return err
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+	"github.com/fabienbellanger/goutils"
+)
+
+// Markdown type.
+type Markdown struct {
+	collapseFiles bool
+}
+
+// NewMarkdown return a pointer to a Markdown.
+// If collapseFiles is true, files list is collapsed inside a <details> block.
+func NewMarkdown(collapseFiles bool) *Markdown {
+	return &Markdown{
+		collapseFiles: collapseFiles,
+	}
+}
+
+// Write displays result as GitHub-flavored Markdown tables.
+// Languages are always displayed, files only if opts.ByFile is true.
+func (m *Markdown) Write(w io.Writer, result *cloc.Result, opts *cloc.Options) error {
+	var sb strings.Builder
+	t := result.Total
+
+	// Languages
+	// ---------
+	markdownHeader(&sb, "Language")
+	for _, l := range sortedLanguages(result.Languages, opts.Sort) {
+		markdownRow(&sb, l.Name, l.Total, l.Size, l.Lines, l.Blanks, l.Comments, l.Docs, l.Code, l.Mixed)
+	}
+	markdownRow(&sb, "**Total**", t.Total, t.Size, t.Lines, t.Blanks, t.Comments, t.Docs, t.Code, t.Mixed)
+
+	// Files
+	// -----
+	if opts.ByFile {
+		sb.WriteString("\n")
+		if m.collapseFiles {
+			sb.WriteString(fmt.Sprintf("<details>\n<summary>Files (%d)</summary>\n\n", len(result.Files)))
+		}
+
+		markdownHeader(&sb, "File")
+		for _, f := range sortedFiles(result.Files, opts.Sort) {
+			markdownRow(&sb, f.Name, "", f.Size, f.Lines, f.Blanks, f.Comments, f.Docs, f.Code, f.Mixed)
+		}
+		markdownRow(&sb, "**Total**", t.Total, t.Size, t.Lines, t.Blanks, t.Comments, t.Docs, t.Code, t.Mixed)
+
+		if m.collapseFiles {
+			sb.WriteString("\n</details>\n")
+		}
+	}
+
+	// Errors
+	// ------
+	if len(result.Errors) > 0 {
+		sb.WriteString(fmt.Sprintf("\n**%d error(s) during analysis:**\n\n", len(result.Errors)))
+		for _, e := range result.Errors {
+			sb.WriteString(fmt.Sprintf("- `%s` (%s): %s\n", e.Path, e.Phase, e.Message))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// markdownHeader writes table header.
+func markdownHeader(sb *strings.Builder, title string) {
+	sb.WriteString(fmt.Sprintf("| %s | Files | Size | Lines | Blanks | Comments | Docs | Code | Mixed |\n", title))
+	sb.WriteString("| :--- | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+}
+
+// markdownRow writes a table row.
+func markdownRow(sb *strings.Builder, title string, files interface{}, size int64, lines, blanks, comments, docs, code, mixed int32) {
+	sb.WriteString(fmt.Sprintf("| %s | %v | %s | %d | %d | %d | %d | %d | %d |\n",
+		markdownEscape(title),
+		files,
+		goutils.HumanSizeWithPrecision(float64(size), 0),
+		lines,
+		blanks,
+		comments,
+		docs,
+		code,
+		mixed))
+}
+
+// markdownEscape escapes characters breaking a table cell.
+func markdownEscape(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
@@ -1,8 +1,62 @@
 package output
 
-import "github.com/fabienbellanger/goCodeAnalyser/cloc"
+import (
+	"io"
+	"sort"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+)
 
 // Writer is an interface for writting on console, JSON, CSV, etc.
+// The result is written to the io.Writer.
 type Writer interface {
-	Write(*cloc.Result, *cloc.Options) error
+	Write(io.Writer, *cloc.Result, *cloc.Options) error
+}
+
+// sortedLanguages returns languages sorted by sortType.
+func sortedLanguages(languages map[string]*cloc.Language, sortType string) []*cloc.Language {
+	languagesSlice := make([]*cloc.Language, 0, len(languages))
+	for k := range languages {
+		languagesSlice = append(languagesSlice, languages[k])
+	}
+
+	switch sortType {
+	case "files":
+		sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByFiles})
+	case "size":
+		sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesBySize})
+	case "lines":
+		sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByLines})
+	case "comments":
+		sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByComments})
+	case "blanks":
+		sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByBlanks})
+	default:
+		sort.Sort(cloc.LanguagesSort{Langs: languagesSlice, LessCmp: cloc.LanguagesByCode})
+	}
+
+	return languagesSlice
+}
+
+// sortedFiles returns files sorted by sortType.
+func sortedFiles(files map[string]*cloc.File, sortType string) []*cloc.File {
+	filesSlice := make([]*cloc.File, 0, len(files))
+	for k := range files {
+		filesSlice = append(filesSlice, files[k])
+	}
+
+	switch sortType {
+	case "size":
+		sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileBySize})
+	case "lines":
+		sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileByLines})
+	case "comments":
+		sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileByComments})
+	case "blanks":
+		sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileByBlanks})
+	default:
+		sort.Sort(cloc.FilesSort{Files: filesSlice, LessCmp: cloc.FileByCode})
+	}
+
+	return filesSlice
 }
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/template"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+	"github.com/fabienbellanger/goutils"
+)
+
+// Template type.
+type Template struct {
+	tmpl *template.Template
+}
+
+// templateFuncs lists helpers available in user templates.
+var templateFuncs = template.FuncMap{
+	// humanSize returns a human readable size (ex.: 12kB).
+	"humanSize": func(s int64) string {
+		return goutils.HumanSizeWithPrecision(float64(s), 0)
+	},
+	// percent returns part/total as a percentage.
+	"percent": func(part, total interface{}) (float64, error) {
+		p, err := toFloat(part)
+		if err != nil {
+			return 0, err
+		}
+		t, err := toFloat(total)
+		if err != nil {
+			return 0, err
+		}
+		if t == 0 {
+			return 0, nil
+		}
+		return p * 100 / t, nil
+	},
+	// sortLanguages returns languages sorted by column (files, size, lines, blanks, comments or code).
+	"sortLanguages": func(sortType string, languages map[string]*cloc.Language) []*cloc.Language {
+		return sortedLanguages(languages, sortType)
+	},
+	// sortFiles returns files sorted by column (size, lines, blanks, comments or code).
+	"sortFiles": func(sortType string, files map[string]*cloc.File) []*cloc.File {
+		return sortedFiles(files, sortType)
+	},
+}
+
+// NewTemplate return a pointer to a Template using the text/template file path.
+func NewTemplate(path string) (*Template, error) {
+	t, err := template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: t}, nil
+}
+
+// Write executes the template against the result.
+func (t *Template) Write(w io.Writer, result *cloc.Result, opts *cloc.Options) error {
+	return t.tmpl.Execute(w, result)
+}
+
+// toFloat converts a number to float64.
+func toFloat(n interface{}) (float64, error) {
+	switch v := n.(type) {
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("percent: invalid number %v", n)
+}
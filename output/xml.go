@@ -0,0 +1,133 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+)
+
+const clocURL = "github.com/fabienbellanger/goCodeAnalyser"
+
+// XML type.
+type XML struct{}
+
+// clocHeader is the header of cloc reports.
+type clocHeader struct {
+	URL            string  `xml:"cloc_url"`
+	Version        string  `xml:"cloc_version"`
+	ElapsedSeconds float64 `xml:"elapsed_seconds"`
+	NFiles         int32   `xml:"n_files"`
+	NLines         int32   `xml:"n_lines"`
+	FilesPerSecond float64 `xml:"files_per_second"`
+	LinesPerSecond float64 `xml:"lines_per_second"`
+}
+
+// xmlLanguage is a language in cloc XML reports.
+type xmlLanguage struct {
+	Name       string `xml:"name,attr"`
+	FilesCount int32  `xml:"files_count,attr"`
+	Blanks     int32  `xml:"blank,attr"`
+	Comments   int32  `xml:"comment,attr"`
+	Docs       int32  `xml:"docs,attr"`
+	Code       int32  `xml:"code,attr"`
+	Mixed      int32  `xml:"mixed,attr"`
+}
+
+// xmlTotal is the total line in cloc XML reports.
+type xmlTotal struct {
+	SumFiles int32 `xml:"sum_files,attr,omitempty"`
+	Blanks   int32 `xml:"blank,attr"`
+	Comments int32 `xml:"comment,attr"`
+	Docs     int32 `xml:"docs,attr"`
+	Code     int32 `xml:"code,attr"`
+	Mixed    int32 `xml:"mixed,attr"`
+}
+
+// xmlLanguages lists languages in cloc XML reports.
+type xmlLanguages struct {
+	Languages []xmlLanguage `xml:"language"`
+	Total     xmlTotal      `xml:"total"`
+}
+
+// xmlFiles lists files in cloc XML reports.
+type xmlFiles struct {
+	Files []*cloc.File `xml:"file"`
+	Total xmlTotal     `xml:"total"`
+}
+
+// xmlResult is the cloc XML representation of a cloc.Result.
+type xmlResult struct {
+	XMLName   xml.Name      `xml:"results"`
+	Header    clocHeader    `xml:"header"`
+	Languages *xmlLanguages `xml:"languages,omitempty"`
+	Files     *xmlFiles     `xml:"files,omitempty"`
+	Errors    *xmlErrors    `xml:"errors,omitempty"`
+}
+
+// xmlErrors lists files errors.
+type xmlErrors struct {
+	Errors []*cloc.FileError `xml:"error"`
+}
+
+// NewXML return a pointer to a XML.
+func NewXML() *XML {
+	return &XML{}
+}
+
+// Write displays result in cloc XML format.
+func (x *XML) Write(w io.Writer, result *cloc.Result, opts *cloc.Options) error {
+	r := xmlResult{Header: newClocHeader(result)}
+	t := result.Total
+
+	if opts.ByFile {
+		r.Files = &xmlFiles{
+			Files: sortedFiles(result.Files, opts.Sort),
+			Total: xmlTotal{Blanks: t.Blanks, Comments: t.Comments, Docs: t.Docs, Code: t.Code, Mixed: t.Mixed},
+		}
+	} else {
+		r.Languages = &xmlLanguages{
+			Total: xmlTotal{SumFiles: t.Total, Blanks: t.Blanks, Comments: t.Comments, Docs: t.Docs, Code: t.Code, Mixed: t.Mixed},
+		}
+		for _, l := range sortedLanguages(result.Languages, opts.Sort) {
+			r.Languages.Languages = append(r.Languages.Languages, xmlLanguage{
+				Name:       l.Name,
+				FilesCount: l.Total,
+				Blanks:     l.Blanks,
+				Comments:   l.Comments,
+				Docs:       l.Docs,
+				Code:       l.Code,
+				Mixed:      l.Mixed,
+			})
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		r.Errors = &xmlErrors{Errors: result.Errors}
+	}
+
+	b, err := xml.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, b)
+
+	return err
+}
+
+// newClocHeader returns the header of cloc reports.
+func newClocHeader(result *cloc.Result) clocHeader {
+	h := clocHeader{
+		URL:            clocURL,
+		Version:        cloc.Version,
+		ElapsedSeconds: result.Elapsed.Seconds(),
+		NFiles:         result.Total.Total,
+		NLines:         result.Total.Lines,
+	}
+	if h.ElapsedSeconds > 0 {
+		h.FilesPerSecond = float64(h.NFiles) / h.ElapsedSeconds
+		h.LinesPerSecond = float64(h.NLines) / h.ElapsedSeconds
+	}
+	return h
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fabienbellanger/goCodeAnalyser/cloc"
+	"gopkg.in/yaml.v2"
+)
+
+// YAML type.
+type YAML struct{}
+
+// NewYAML return a pointer to a YAML.
+func NewYAML() *YAML {
+	return &YAML{}
+}
+
+// Write displays result in cloc YAML format.
+func (y *YAML) Write(w io.Writer, result *cloc.Result, opts *cloc.Options) error {
+	h := newClocHeader(result)
+	header := yaml.MapSlice{
+		{Key: "cloc_url", Value: h.URL},
+		{Key: "cloc_version", Value: h.Version},
+		{Key: "elapsed_seconds", Value: h.ElapsedSeconds},
+		{Key: "n_files", Value: h.NFiles},
+		{Key: "n_lines", Value: h.NLines},
+		{Key: "files_per_second", Value: h.FilesPerSecond},
+		{Key: "lines_per_second", Value: h.LinesPerSecond},
+	}
+
+	// Errors are in header to keep top-level keys for languages (or files) only
+	if len(result.Errors) > 0 {
+		errors := make([]yaml.MapSlice, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			errors = append(errors, yaml.MapSlice{
+				{Key: "path", Value: e.Path},
+				{Key: "phase", Value: e.Phase},
+				{Key: "error", Value: e.Message},
+			})
+		}
+		header = append(header, yaml.MapItem{Key: "errors", Value: errors})
+	}
+
+	r := yaml.MapSlice{{Key: "header", Value: header}}
+
+	if opts.ByFile {
+		for _, f := range sortedFiles(result.Files, opts.Sort) {
+			r = append(r, yaml.MapItem{Key: f.Name, Value: yaml.MapSlice{
+				{Key: "blank", Value: f.Blanks},
+				{Key: "comment", Value: f.Comments},
+				{Key: "code", Value: f.Code},
+				{Key: "mixed", Value: f.Mixed},
+				{Key: "docs", Value: f.Docs},
+				{Key: "language", Value: f.Language},
+			}})
+		}
+	} else {
+		for _, l := range sortedLanguages(result.Languages, opts.Sort) {
+			r = append(r, yaml.MapItem{Key: l.Name, Value: yaml.MapSlice{
+				{Key: "nFiles", Value: l.Total},
+				{Key: "blank", Value: l.Blanks},
+				{Key: "comment", Value: l.Comments},
+				{Key: "code", Value: l.Code},
+				{Key: "mixed", Value: l.Mixed},
+				{Key: "docs", Value: l.Docs},
+			}})
+		}
+	}
+
+	t := result.Total
+	r = append(r, yaml.MapItem{Key: "SUM", Value: yaml.MapSlice{
+		{Key: "blank", Value: t.Blanks},
+		{Key: "comment", Value: t.Comments},
+		{Key: "code", Value: t.Code},
+		{Key: "mixed", Value: t.Mixed},
+		{Key: "docs", Value: t.Docs},
+		{Key: "nFiles", Value: t.Total},
+	}})
+
+	b, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "---\n# %s\n%s", clocURL, b)
+
+	return err
+}